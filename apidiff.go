@@ -0,0 +1,413 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+)
+
+// apiSymbol is an exported symbol compared by an APIDiff request.
+type apiSymbol struct {
+	name string
+	kind string
+	file string
+	pos  token.Pos
+	// def is the normalized definition compared between versions: the parameter and result types
+	// of functions and methods, the type of fields and variables, the type and value of constants,
+	// and the type expression of types, or just struct or interface for them.
+	def string
+	// ptrRecv reports whether a method has a pointer receiver.
+	ptrRecv bool
+	// iface reports whether the symbol is a method or an embedded type of an interface, and sealed
+	// whether that interface has unexported methods, so it can't be implemented by other packages.
+	iface  bool
+	sealed bool
+}
+
+// apiSet is the exported API of a version of the sources, in declaration order.
+type apiSet struct {
+	list   []*apiSymbol
+	byName map[string]*apiSymbol
+}
+
+// add appends a symbol to the set. Redeclared symbols are ignored.
+func (s *apiSet) add(sym *apiSymbol) {
+	if _, ok := s.byName[sym.name]; ok {
+		return
+	}
+
+	s.list = append(s.list, sym)
+	s.byName[sym.name] = sym
+}
+
+// getAPIDiff replies a msg.APIDiff request with the changes of the exported API from m.OldFiles to
+// m.Files, or from m.Old to m.Content if there are no files. The comparison is syntactic: every
+// change of the declared types breaks the compatibility, even if both types are identical.
+func getAPIDiff(m *msg.Request) *msg.Response {
+	res := newResponse()
+	oldFiles, newFiles := m.OldFiles, m.Files
+	if len(oldFiles) == 0 && len(newFiles) == 0 {
+		oldFiles = []*msg.SourceFile{{Name: "old.go", Content: m.Old}}
+		newFiles = []*msg.SourceFile{{Name: "source.go", Content: m.Content}}
+	}
+
+	fset := token.NewFileSet()
+	res.Status = msg.Ok
+	oldAPI := getAPISet(res, fset, oldFiles)
+	newAPI := getAPISet(res, fset, newFiles)
+	if res.Status == msg.Fatal {
+		return res
+	}
+
+	res.APIChanges = []*msg.APIChange{}
+	for _, sym := range newAPI.list {
+		old, ok := oldAPI.byName[sym.name]
+		if !ok {
+			if parent, ok := apiParent(sym.name); ok && !samePart(oldAPI, newAPI, parent) {
+				continue
+			}
+
+			change := &msg.APIChange{Change: "added", Compatible: true, Message: sym.kind + " added"}
+			if sym.iface && !sym.sealed {
+				change.Compatible = false
+				change.Message = "method added to an interface which can be implemented by other packages"
+				if sym.kind == "embedded" {
+					change.Message = "type embedded in an interface which can be implemented by other packages"
+				}
+			}
+
+			res.APIChanges = append(res.APIChanges, setAPISymbol(fset, change, nil, sym))
+			continue
+		}
+
+		if parent, ok := apiParent(sym.name); ok && !samePart(oldAPI, newAPI, parent) {
+			continue
+		}
+
+		change := &msg.APIChange{Change: "changed"}
+		switch {
+		case old.kind != sym.kind:
+			change.Message = "changed from " + old.kind + " to " + sym.kind
+		case old.def != sym.def:
+			change.Message = apiChangeMessage(sym.kind)
+		case !old.ptrRecv && sym.ptrRecv:
+			change.Message = "receiver changed from value to pointer"
+		case old.ptrRecv && !sym.ptrRecv:
+			change.Compatible = true
+			change.Message = "receiver changed from pointer to value"
+		default:
+			continue
+		}
+
+		res.APIChanges = append(res.APIChanges, setAPISymbol(fset, change, old, sym))
+	}
+
+	for _, sym := range oldAPI.list {
+		if _, ok := newAPI.byName[sym.name]; ok {
+			continue
+		}
+
+		if parent, ok := apiParent(sym.name); ok && !samePart(oldAPI, newAPI, parent) {
+			continue
+		}
+
+		change := &msg.APIChange{Change: "removed", Message: sym.kind + " removed"}
+		if sym.iface {
+			// removing a method from an interface breaks its callers, but not its implementations
+			change.Message = "method removed from an interface"
+			if sym.kind == "embedded" {
+				change.Message = "type removed from an interface"
+			}
+		}
+
+		res.APIChanges = append(res.APIChanges, setAPISymbol(fset, change, sym, nil))
+	}
+
+	return res
+}
+
+// apiChangeMessage describes the change of the definition of a kind of symbol.
+func apiChangeMessage(kind string) string {
+	switch kind {
+	case "func", "method":
+		return "signature changed"
+	case "const":
+		return "type or value changed"
+	case "type":
+		return "definition changed"
+	}
+
+	return "type changed"
+}
+
+// apiParent returns the type a method or field belongs to.
+func apiParent(name string) (string, bool) {
+	i := strings.Index(name, ".")
+	if i < 0 {
+		return "", false
+	}
+
+	return name[:i], true
+}
+
+// samePart reports whether a type is in both versions with the same definition, so the changes of
+// its methods and fields are reported. Otherwise the change of the type covers them. A type in none
+// of them is declared in other files, so the changes of its methods are reported too.
+func samePart(oldAPI, newAPI *apiSet, name string) bool {
+	old, inOld := oldAPI.byName[name]
+	sym, inNew := newAPI.byName[name]
+	if !inOld && !inNew {
+		return true
+	}
+
+	return inOld && inNew && old.kind == sym.kind && old.def == sym.def
+}
+
+// setAPISymbol fills the name, kind and positions of a change from the old and new symbols.
+func setAPISymbol(fset *token.FileSet, change *msg.APIChange, old, sym *apiSymbol) *msg.APIChange {
+	if old != nil {
+		change.Symbol, change.Kind = old.name, old.kind
+		change.Old = &msg.APIPosition{File: old.file, Start: getPosition(fset, old.pos)}
+	}
+
+	if sym != nil {
+		change.Symbol, change.Kind = sym.name, sym.kind
+		change.New = &msg.APIPosition{File: sym.file, Start: getPosition(fset, sym.pos)}
+	}
+
+	return change
+}
+
+// getAPISet parses the files and collects their exported API. The status and errors of the files
+// are added to the response; a file which can't be parsed makes it fatal.
+func getAPISet(res *msg.Response, fset *token.FileSet, files []*msg.SourceFile) *apiSet {
+	api := &apiSet{byName: make(map[string]*apiSymbol)}
+	for _, f := range files {
+		tree, status, errors := parse(fset, f.Name, f.Content)
+		res.Errors = append(res.Errors, errors...)
+		switch {
+		case status == msg.Fatal:
+			res.Status = msg.Fatal
+		case status == msg.Error && res.Status == msg.Ok:
+			res.Status = msg.Error
+		}
+
+		if tree != nil && !strings.HasSuffix(f.Name, "_test.go") {
+			addAPISymbols(api, fset, f.Name, tree, f.Content)
+		}
+	}
+
+	return api
+}
+
+// addAPISymbols adds the exported declarations of a file to the API: functions, methods of exported
+// types, types with their exported fields and interface methods, variables and constants.
+func addAPISymbols(api *apiSet, fset *token.FileSet, name string, tree *ast.File, src string) {
+	normalize := tokenNormalizer(fset, tree, src)
+	exprDef := func(expr ast.Expr) string {
+		if expr == nil {
+			return ""
+		}
+
+		return normalize(expr.Pos(), expr.End())
+	}
+
+	for _, decl := range tree.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if !decl.Name.IsExported() {
+				continue
+			}
+
+			sym := &apiSymbol{
+				name: decl.Name.Name,
+				kind: "func",
+				file: name,
+				pos:  decl.Name.Pos(),
+				def:  apiSignature(exprDef, decl.Type),
+			}
+
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				recv := decl.Recv.List[0].Type
+				base := recvBase(recv)
+				if !ast.IsExported(base) {
+					continue
+				}
+
+				sym.name = base + "." + sym.name
+				sym.kind = "method"
+				_, sym.ptrRecv = recv.(*ast.StarExpr)
+			}
+
+			api.add(sym)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Name.IsExported() {
+						addAPIType(api, exprDef, name, spec)
+					}
+				case *ast.ValueSpec:
+					kind := strings.ToLower(decl.Tok.String())
+					def := exprDef(spec.Type)
+					if kind == "const" {
+						def += " = " + joinDefs(exprDef, spec.Values)
+					}
+
+					for _, id := range spec.Names {
+						if id.IsExported() {
+							api.add(&apiSymbol{name: id.Name, kind: kind, file: name, pos: id.Pos(), def: def})
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// addAPIType adds an exported type to the API. The exported fields of structs and the methods and
+// embedded types of interfaces are added as symbols of their own.
+func addAPIType(api *apiSet, exprDef func(ast.Expr) string, file string, spec *ast.TypeSpec) {
+	sym := &apiSymbol{name: spec.Name.Name, kind: "type", file: file, pos: spec.Name.Pos()}
+	if spec.TypeParams != nil {
+		sym.def = "[" + joinDefs(exprDef, fieldTypes(spec.TypeParams)) + "] "
+	}
+
+	if spec.Assign.IsValid() {
+		sym.def += "= "
+	}
+
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		sym.def += "struct"
+		api.add(sym)
+		for _, field := range t.Fields.List {
+			names := fieldNames(field)
+			for _, id := range names {
+				if id.IsExported() {
+					api.add(&apiSymbol{
+						name: sym.name + "." + id.Name,
+						kind: "field",
+						file: file,
+						pos:  id.Pos(),
+						def:  exprDef(field.Type),
+					})
+				}
+			}
+		}
+	case *ast.InterfaceType:
+		sym.def += "interface"
+		api.add(sym)
+		sealed := false
+		for _, field := range t.Methods.List {
+			for _, id := range field.Names {
+				sealed = sealed || !id.IsExported()
+			}
+		}
+
+		for _, field := range t.Methods.List {
+			if len(field.Names) == 0 {
+				def := exprDef(field.Type)
+				api.add(&apiSymbol{
+					name:   sym.name + "." + strings.Replace(def, " ", "", -1),
+					kind:   "embedded",
+					file:   file,
+					pos:    field.Type.Pos(),
+					def:    def,
+					iface:  true,
+					sealed: sealed,
+				})
+
+				continue
+			}
+
+			for _, id := range field.Names {
+				if id.IsExported() {
+					api.add(&apiSymbol{
+						name:   sym.name + "." + id.Name,
+						kind:   "method",
+						file:   file,
+						pos:    id.Pos(),
+						def:    apiSignature(exprDef, field.Type.(*ast.FuncType)),
+						iface:  true,
+						sealed: sealed,
+					})
+				}
+			}
+		}
+	default:
+		sym.def += exprDef(spec.Type)
+		api.add(sym)
+	}
+}
+
+// fieldNames returns the names of a field. Embedded fields are named after their type.
+func fieldNames(field *ast.Field) []*ast.Ident {
+	if len(field.Names) > 0 {
+		return field.Names
+	}
+
+	expr := field.Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			expr = e.Sel
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return []*ast.Ident{e}
+		default:
+			return nil
+		}
+	}
+}
+
+// apiSignature writes the type parameters, parameters and results of a function without their
+// names, so renaming a parameter doesn't change the API.
+func apiSignature(exprDef func(ast.Expr) string, ftype *ast.FuncType) string {
+	var b strings.Builder
+	if ftype.TypeParams != nil {
+		b.WriteString("[" + joinDefs(exprDef, fieldTypes(ftype.TypeParams)) + "]")
+	}
+
+	b.WriteString("(" + joinDefs(exprDef, fieldTypes(ftype.Params)) + ")")
+	if ftype.Results != nil {
+		b.WriteString(" (" + joinDefs(exprDef, fieldTypes(ftype.Results)) + ")")
+	}
+
+	return b.String()
+}
+
+// fieldTypes returns the type of every name of a field list, or the type of every field without
+// names.
+func fieldTypes(list *ast.FieldList) []ast.Expr {
+	if list == nil {
+		return nil
+	}
+
+	var types []ast.Expr
+	for _, field := range list.List {
+		types = append(types, field.Type)
+		for i := 1; i < len(field.Names); i++ {
+			types = append(types, field.Type)
+		}
+	}
+
+	return types
+}
+
+// joinDefs writes the definitions of a list of expressions separated by commas.
+func joinDefs(exprDef func(ast.Expr) string, list []ast.Expr) string {
+	defs := make([]string, 0, len(list))
+	for _, expr := range list {
+		defs = append(defs, exprDef(expr))
+	}
+
+	return strings.Join(defs, ", ")
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+const apiOld = `package p
+
+import "io"
+
+const Max = 10
+
+var Default = New()
+
+func New() *Client { return nil }
+
+func Open(name string, flag int) error { return nil }
+
+func Helper() {}
+
+type Client struct {
+	Name string
+	Addr string
+	conn io.Closer
+}
+
+func (c Client) Close() error { return nil }
+
+type Reader interface {
+	Read(p []byte) (int, error)
+	Reset()
+}
+
+type Kind int
+`
+
+const apiNew = `package p
+
+import "io"
+
+const Max = 20
+
+var Default = New()
+
+func New() *Client { return nil }
+
+func Open(path string,
+	flag int,
+) error {
+	return nil
+}
+
+func Dial(addr string) (*Client, error) { return nil, nil }
+
+type Client struct {
+	Name    string
+	Addr    []string
+	Timeout int
+	conn    io.Closer
+}
+
+func (c *Client) Close() error { return nil }
+
+type Reader interface {
+	Read(p []byte) (int, error)
+	io.Closer
+}
+
+type Kind string
+`
+
+func TestGetAPIDiff(t *testing.T) {
+	res := getResponse(&msg.Request{Action: msg.APIDiff, Old: apiOld, Content: apiNew})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+
+	pos := func(file string, offset, line, column int) *msg.APIPosition {
+		return &msg.APIPosition{File: file, Start: msg.Position{Offset: offset, Line: line, Column: column}}
+	}
+
+	require.Equal(t, []*msg.APIChange{
+		{Symbol: "Max", Kind: "const", Change: "changed", Message: "type or value changed", Old: pos("old.go", 30, 5, 7), New: pos("source.go", 30, 5, 7)},
+		{Symbol: "Dial", Kind: "func", Change: "added", Compatible: true, Message: "func added", New: pos("source.go", 160, 17, 6)},
+		{Symbol: "Client.Addr", Kind: "field", Change: "changed", Message: "type changed", Old: pos("old.go", 204, 17, 2), New: pos("source.go", 254, 21, 2)},
+		{Symbol: "Client.Timeout", Kind: "field", Change: "added", Compatible: true, Message: "field added", New: pos("source.go", 272, 22, 2)},
+		{Symbol: "Client.Close", Kind: "method", Change: "changed", Message: "receiver changed from value to pointer", Old: pos("old.go", 251, 21, 17), New: pos("source.go", 323, 26, 18)},
+		{Symbol: "Reader.io.Closer", Kind: "embedded", Change: "added", Message: "type embedded in an interface which can be implemented by other packages", New: pos("source.go", 407, 30, 2)},
+		{Symbol: "Kind", Kind: "type", Change: "changed", Message: "definition changed", Old: pos("old.go", 351, 28, 6), New: pos("source.go", 425, 33, 6)},
+		{Symbol: "Helper", Kind: "func", Change: "removed", Message: "func removed", Old: pos("old.go", 156, 13, 6)},
+		{Symbol: "Reader.Reset", Kind: "method", Change: "removed", Message: "method removed from an interface", Old: pos("old.go", 335, 25, 2)},
+	}, res.APIChanges)
+}
+
+func TestGetAPIDiffFiles(t *testing.T) {
+	req := &msg.Request{
+		Action: msg.APIDiff,
+		OldFiles: []*msg.SourceFile{
+			{Name: "a.go", Content: "package p\n\ntype T interface {\n\tM()\n\tm()\n}\n"},
+			{Name: "b.go", Content: "package p\n\nfunc (t T) Old() {}\n"},
+		},
+		Files: []*msg.SourceFile{
+			{Name: "a.go", Content: "package p\n\ntype T interface {\n\tM()\n\tN()\n\tm()\n}\n"},
+			{Name: "c.go", Content: "package p\n\nfunc (t T) Old() {}\n"},
+			{Name: "c_test.go", Content: "package p\n\nfunc Test() {}\n"},
+		},
+	}
+
+	res := getResponse(req)
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Equal(t, []*msg.APIChange{
+		{
+			Symbol:     "T.N",
+			Kind:       "method",
+			Change:     "added",
+			Compatible: true,
+			Message:    "method added",
+			New:        &msg.APIPosition{File: "a.go", Start: msg.Position{Offset: 36, Line: 5, Column: 2}},
+		},
+	}, res.APIChanges)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"golang.org/x/tools/txtar"
+)
+
+// archiveName is the file name the error positions of a txtar archive refer to.
+const archiveName = "source.txtar"
+
+// getParseArchive replies a request whose content is a txtar archive. The .go members are parsed
+// as the files of a msg.ParsePackage request, and the go.mod and go.sum members as their own
+// actions do; any other member is ignored. The positions in the results are relative to each
+// member, but the errors point at the lines of the archive.
+func getParseArchive(m *msg.Request) *msg.Response {
+	res := newResponse()
+	archive := txtar.Parse([]byte(m.Content))
+	if len(archive.Files) == 0 {
+		res.Status = msg.Fatal
+		res.Errors = []string{"no files in the archive"}
+		return res
+	}
+
+	starts := make(map[string]msg.Position)
+	var files []*msg.SourceFile
+	res.Status = msg.Ok
+	markers := getArchiveStarts(m.Content)
+	for i, f := range archive.Files {
+		start := markers[i]
+		if _, ok := starts[f.Name]; !ok {
+			starts[f.Name] = start
+		}
+
+		var member *msg.Response
+		switch {
+		case strings.HasSuffix(f.Name, ".go"):
+			files = append(files, &msg.SourceFile{Name: f.Name, Content: string(f.Data)})
+			continue
+		case f.Name == "go.mod":
+			member = getParseGoMod(&msg.Request{Content: string(f.Data)})
+			res.ModFile = member.ModFile
+		case f.Name == "go.sum":
+			member = getParseGoSum(&msg.Request{Content: string(f.Data)})
+			res.GoSum = member.GoSum
+		default:
+			continue
+		}
+
+		if member.Status != msg.Ok {
+			if res.Status != msg.Fatal {
+				res.Status = member.Status
+			}
+
+			res.Errors = append(res.Errors, member.Errors...)
+		}
+	}
+
+	if len(files) > 0 {
+		fset := token.NewFileSet()
+		res.Package = parsePackage(fset, files)
+		res.Errors = append(res.Errors, checkPackage(fset, res.Package)...)
+		for _, f := range res.Package.Files {
+			if f.Status != msg.Ok && res.Status != msg.Fatal {
+				res.Status = msg.Error
+			}
+
+			f.Errors = getArchiveErrors(f.Errors, starts)
+			if f.AST != nil {
+				ast.Inspect(f.AST, setObjNil)
+			}
+		}
+	}
+
+	if len(res.Errors) > 0 && res.Status == msg.Ok {
+		res.Status = msg.Error
+	}
+
+	res.Errors = getArchiveErrors(res.Errors, starts)
+	return res
+}
+
+// getArchiveStarts returns the position in a txtar archive where the data of every member begins,
+// that is the line after its file marker.
+func getArchiveStarts(content string) []msg.Position {
+	var starts []msg.Position
+	offset := 0
+	for i, line := range strings.SplitAfter(content, "\n") {
+		offset += len(line)
+		if isArchiveMarker(line) {
+			starts = append(starts, msg.Position{Offset: offset, Line: i + 2, Column: 1})
+		}
+	}
+
+	return starts
+}
+
+// isArchiveMarker reports whether a line is a txtar file marker: "-- NAME --", with a name which
+// isn't blank.
+func isArchiveMarker(line string) bool {
+	line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+	return strings.HasPrefix(line, "-- ") && strings.HasSuffix(line, " --") && len(line) >= len("--  --") &&
+		strings.TrimSpace(line[3:len(line)-3]) != ""
+}
+
+// getArchiveErrors rewrites the "name:line" and "name:line:column" positions of the members in a
+// list of errors to the lines of the archive.
+func getArchiveErrors(errors []string, starts map[string]msg.Position) []string {
+	if len(errors) == 0 {
+		return errors
+	}
+
+	names := make([]string, 0, len(starts))
+	for name := range starts {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+
+	re := regexp.MustCompile(`(^|[\s(])(` + strings.Join(names, "|") + `):(\d+)`)
+
+	list := make([]string, 0, len(errors))
+	for _, err := range errors {
+		list = append(list, re.ReplaceAllStringFunc(err, func(s string) string {
+			match := re.FindStringSubmatch(s)
+			line, _ := strconv.Atoi(match[3])
+			return fmt.Sprintf("%s%s:%d", match[1], archiveName, starts[match[2]].Line+line-1)
+		}))
+	}
+
+	return list
+}
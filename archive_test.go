@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testArchive = `Fixture comment.
+-- go.mod --
+module example.com/foo
+
+go 1.21
+-- a.go --
+package foo
+
+func F() {}
+-- b.go --
+package foo
+
+var F = 1
+-- c.go --
+package foo
+
+func {
+-- README --
+ignored
+`
+
+func TestGetParseArchive(t *testing.T) {
+	res := getResponse(&msg.Request{Action: msg.ParsePackage, Content: testArchive, Archive: true})
+	require.Equal(t, msg.Error, res.Status)
+	require.Equal(t, []string{
+		"source.txtar:13:5: F redeclared in this block (other declaration at source.txtar:9:6)",
+	}, res.Errors)
+
+	require.Equal(t, "example.com/foo", res.ModFile.Module.Path)
+	require.Equal(t, "1.21", res.ModFile.Go.Version)
+
+	require.Equal(t, "foo", res.Package.Name)
+	require.Len(t, res.Package.Files, 3)
+	for i, name := range []string{"a.go", "b.go", "c.go"} {
+		require.Equal(t, name, res.Package.Files[i].Name)
+		require.NotNil(t, res.Package.Files[i].AST)
+	}
+
+	// positions of the results are relative to each member
+	require.Equal(t, msg.Position{Offset: 18, Line: 3, Column: 6}, res.Package.Scope[0].Start)
+
+	c := res.Package.Files[2]
+	require.Equal(t, msg.Error, c.Status)
+	require.Equal(t, "source.txtar:17:6: expected 'IDENT', found '{'", c.Errors[0])
+}
+
+func TestGetParseArchiveErrors(t *testing.T) {
+	res := getResponse(&msg.Request{Content: "-- go.mod --\nmodule\n-- go.sum --\nfoo v1.0.0\n", Archive: true})
+	require.Equal(t, msg.Error, res.Status)
+	require.Equal(t, []string{
+		"source.txtar:2:1: usage: module module/path",
+		"source.txtar:4: malformed line: want 3 fields, got 2",
+	}, res.Errors)
+	require.Nil(t, res.Package)
+	require.Empty(t, res.GoSum)
+
+	res = getResponse(&msg.Request{Content: "just a comment\n", Archive: true})
+	require.Equal(t, msg.Fatal, res.Status)
+	require.Equal(t, []string{"no files in the archive"}, res.Errors)
+
+	res = getResponse(&msg.Request{Action: msg.Format, Content: "-- a.go --\npackage foo\n", Archive: true})
+	require.Equal(t, msg.Fatal, res.Status)
+	require.Equal(t, []string{`action "Format" doesn't accept archives`}, res.Errors)
+}
+
+func TestGetArchiveStarts(t *testing.T) {
+	require.Equal(t, []msg.Position{
+		{Offset: 12, Line: 2, Column: 1},
+		{Offset: 27, Line: 4, Column: 1},
+	}, getArchiveStarts("-- a.go --\r\nx\n--  b.go  --\n-- --\n"))
+}
+
+func TestGetParseArchiveBlankMarker(t *testing.T) {
+	content := "-- a.go --\npackage foo\n--  --\n\nvar x = 1\n-- b.go --\npackage foo\n\nfunc {\n"
+	require.Equal(t, []msg.Position{
+		{Offset: 11, Line: 2, Column: 1},
+		{Offset: 52, Line: 7, Column: 1},
+	}, getArchiveStarts(content))
+
+	res := getResponse(&msg.Request{Action: msg.ParsePackage, Content: content, Archive: true})
+	require.Equal(t, msg.Error, res.Status)
+	require.Len(t, res.Package.Files, 2)
+	require.Equal(t, "b.go", res.Package.Files[1].Name)
+	require.Equal(t, "source.txtar:9:6: expected 'IDENT', found '{'", res.Package.Files[1].Errors[0])
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+)
+
+// changeDecl is a declaration compared by a Changes request. The signature and the body are its
+// tokens, so whitespace and comments don't make a difference.
+type changeDecl struct {
+	key       string
+	name      string
+	kind      string
+	recv      string
+	start     msg.Position
+	signature string
+	body      string
+}
+
+// getChanges replies a msg.Changes request with the functions, methods and types which were added,
+// removed or changed from m.Old to m.Content. The added and changed declarations are listed in the
+// order of the new content, followed by the removed ones in the order of the old content.
+func getChanges(m *msg.Request) *msg.Response {
+	res := newResponse()
+	fset := token.NewFileSet()
+	oldTree, newTree, ok := parseRevisions(res, fset, m)
+	if !ok {
+		return res
+	}
+
+	oldDecls := getChangeDecls(fset, oldTree, m.Old)
+	newDecls := getChangeDecls(fset, newTree, m.Content)
+	oldByKey := make(map[string]*changeDecl)
+	for _, d := range oldDecls {
+		oldByKey[d.key] = d
+	}
+
+	res.Changes = []*msg.DeclChange{}
+	newKeys := make(map[string]bool)
+	for _, d := range newDecls {
+		newKeys[d.key] = true
+		change := &msg.DeclChange{Name: d.name, Kind: d.kind, Recv: d.recv, New: &d.start}
+		o, ok := oldByKey[d.key]
+		switch {
+		case !ok:
+			change.Change = "added"
+		case o.signature != d.signature && d.kind == "type":
+			change.Change = "definition"
+		case o.signature != d.signature:
+			change.Change = "signature"
+		case o.body != d.body:
+			change.Change = "body"
+		default:
+			continue
+		}
+
+		if ok {
+			change.Old = &o.start
+		}
+
+		res.Changes = append(res.Changes, change)
+	}
+
+	for _, o := range oldDecls {
+		if !newKeys[o.key] {
+			res.Changes = append(res.Changes, &msg.DeclChange{
+				Name:   o.name,
+				Kind:   o.kind,
+				Recv:   o.recv,
+				Change: "removed",
+				Old:    &o.start,
+			})
+		}
+	}
+
+	return res
+}
+
+// getChangeDecls returns the function, method and type declarations of a file. Methods are
+// identified by the base type of their receiver, so changing a value receiver to a pointer one is a
+// signature change. Declarations sharing a name, like init functions, are told apart by their order.
+func getChangeDecls(fset *token.FileSet, tree *ast.File, src string) []*changeDecl {
+	normalize := tokenNormalizer(fset, tree, src)
+	var decls []*changeDecl
+	count := make(map[string]int)
+	add := func(d *changeDecl) {
+		count[d.key]++
+		if n := count[d.key]; n > 1 {
+			d.key = fmt.Sprintf("%s#%d", d.key, n)
+		}
+
+		decls = append(decls, d)
+	}
+
+	for _, decl := range tree.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			d := &changeDecl{
+				key:       "func " + decl.Name.Name,
+				name:      decl.Name.Name,
+				kind:      "func",
+				start:     getPosition(fset, decl.Pos()),
+				signature: normalize(decl.Pos(), decl.Type.End()),
+			}
+
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				d.kind = "method"
+				d.recv = render(fset, decl.Recv.List[0].Type)
+				d.key = "method " + recvBase(decl.Recv.List[0].Type) + "." + decl.Name.Name
+			}
+
+			if decl.Body != nil {
+				d.body = normalize(decl.Body.Pos(), decl.Body.End())
+			}
+
+			add(d)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				s, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+
+				add(&changeDecl{
+					key:       "type " + s.Name.Name,
+					name:      s.Name.Name,
+					kind:      "type",
+					start:     getPosition(fset, s.Pos()),
+					signature: normalize(s.Pos(), s.End()),
+				})
+			}
+		}
+	}
+
+	return decls
+}
+
+// tokenNormalizer returns a function which writes the tokens of a file between two positions, so
+// comparing the results ignores whitespace, comments and trailing commas.
+func tokenNormalizer(fset *token.FileSet, tree *ast.File, src string) func(start, end token.Pos) string {
+	// the package clause may be missing in files with errors
+	file := fset.File(tree.FileStart)
+	tokens := scanTokens(file, []byte(src))
+	return func(start, end token.Pos) string {
+		in := tokensIn(tokens, file.Offset(start), file.Offset(end))
+		list := make([]string, 0, len(in))
+		for i, t := range in {
+			if t.tok == token.COMMA && i+1 < len(in) {
+				switch in[i+1].tok {
+				case token.RPAREN, token.RBRACK, token.RBRACE:
+					continue
+				}
+			}
+
+			if t.lit != "" {
+				list = append(list, t.lit)
+			} else {
+				list = append(list, t.tok.String())
+			}
+		}
+
+		return strings.Join(list, " ")
+	}
+}
+
+// recvBase returns the name of the base type of a receiver, without pointers nor type parameters.
+func recvBase(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
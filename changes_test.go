@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetChanges(t *testing.T) {
+	old := `package p
+
+// A does a.
+func A(x int) int { return x }
+
+func B() { println("b") }
+
+func (t T) M() {}
+
+type T struct{ a int }
+
+func C() {}
+`
+	newContent := `package p
+
+// A does a, documented differently.
+func A(x int) int {
+	return x // same
+}
+
+func B() { println("bb") }
+
+func (t *T) M() {}
+
+type T struct {
+	a int
+	b string
+}
+
+func D() {}
+`
+
+	res := getResponse(&msg.Request{Action: msg.Changes, Old: old, Content: newContent})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Equal(t, []*msg.DeclChange{
+		{
+			Name:   "B",
+			Kind:   "func",
+			Change: "body",
+			Old:    &msg.Position{Offset: 56, Line: 6, Column: 1},
+			New:    &msg.Position{Offset: 89, Line: 8, Column: 1},
+		},
+		{
+			Name:   "M",
+			Kind:   "method",
+			Recv:   "*T",
+			Change: "signature",
+			Old:    &msg.Position{Offset: 83, Line: 8, Column: 1},
+			New:    &msg.Position{Offset: 117, Line: 10, Column: 1},
+		},
+		{
+			Name:   "T",
+			Kind:   "type",
+			Change: "definition",
+			Old:    &msg.Position{Offset: 107, Line: 10, Column: 6},
+			New:    &msg.Position{Offset: 142, Line: 12, Column: 6},
+		},
+		{
+			Name:   "D",
+			Kind:   "func",
+			Change: "added",
+			New:    &msg.Position{Offset: 173, Line: 17, Column: 1},
+		},
+		{
+			Name:   "C",
+			Kind:   "func",
+			Change: "removed",
+			Old:    &msg.Position{Offset: 126, Line: 12, Column: 1},
+		},
+	}, res.Changes)
+}
+
+func TestGetChangesInit(t *testing.T) {
+	old := "package p\n\nfunc init() {}\n\nfunc init() { a() }\n"
+	newContent := "package p\n\nfunc init() {}\n"
+
+	res := getResponse(&msg.Request{Action: msg.Changes, Old: old, Content: newContent})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Len(t, res.Changes, 1)
+	require.Equal(t, "removed", res.Changes[0].Change)
+	require.Equal(t, 5, res.Changes[0].Old.Line)
+
+	res = getResponse(&msg.Request{Action: msg.Changes, Old: newContent, Content: newContent})
+	require.Equal(t, msg.Ok, res.Status)
+	require.Empty(t, res.Changes)
+}
@@ -0,0 +1,492 @@
+package main
+
+import (
+	"encoding/binary"
+	"go/ast"
+	"go/token"
+	"hash/fnv"
+	"sort"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+)
+
+const (
+	// diffMinHeight is the minimum height of the isomorphic subtrees matched top-down.
+	diffMinHeight = 2
+	// diffMinDice is the minimum ratio of common descendants to match two containers bottom-up.
+	diffMinDice = 0.5
+)
+
+// diffNode is a node of the trees compared by a Diff request.
+type diffNode struct {
+	node     ast.Node
+	typ      string
+	label    string
+	parent   *diffNode
+	children []*diffNode
+	// id is the pre-order index of the node, so the descendants of a node have the ids between
+	// id+1 and id+size-1.
+	id     int
+	size   int
+	height int
+	hash   uint64
+}
+
+// isDescendant reports whether d is a descendant of n.
+func (n *diffNode) isDescendant(d *diffNode) bool {
+	return d.id > n.id && d.id < n.id+n.size
+}
+
+// diffMatcher maps the nodes of the old tree to the nodes of the new one.
+type diffMatcher struct {
+	src, dst []*diffNode
+	srcToDst map[*diffNode]*diffNode
+	dstToSrc map[*diffNode]*diffNode
+}
+
+// getASTDiff replies a msg.Diff request with the edit script which turns the AST of m.Old into the AST
+// of m.Content. The nodes are matched as GumTree does: first the largest isomorphic subtrees
+// top-down, then the containers which share enough matched descendants bottom-up.
+func getASTDiff(m *msg.Request) *msg.Response {
+	res := newResponse()
+	fset := token.NewFileSet()
+	oldTree, newTree, ok := parseRevisions(res, fset, m)
+	if !ok {
+		return res
+	}
+
+	dm := &diffMatcher{
+		src:      getDiffTree(oldTree),
+		dst:      getDiffTree(newTree),
+		srcToDst: make(map[*diffNode]*diffNode),
+		dstToSrc: make(map[*diffNode]*diffNode),
+	}
+
+	dm.matchTopDown()
+	dm.matchBottomUp()
+	res.Edits = dm.editScript(fset)
+	return res
+}
+
+// parseRevisions parses m.Old as old.go and m.Content as source.go, and sets the status and errors
+// of both in the response. If the returned bool is false the response is fatal and the trees
+// mustn't be used.
+func parseRevisions(res *msg.Response, fset *token.FileSet, m *msg.Request) (*ast.File, *ast.File, bool) {
+	oldTree, oldStatus, oldErrors := parse(fset, "old.go", m.Old)
+	newTree, newStatus, newErrors := parse(fset, "source.go", m.Content)
+	res.Errors = append(oldErrors, newErrors...)
+	switch {
+	case oldStatus == msg.Fatal || newStatus == msg.Fatal:
+		res.Status = msg.Fatal
+		return nil, nil, false
+	case oldStatus == msg.Error || newStatus == msg.Error:
+		res.Status = msg.Error
+	default:
+		res.Status = msg.Ok
+	}
+
+	return oldTree, newTree, true
+}
+
+// getDiffTree converts an AST in a diffNode tree and returns its nodes in pre-order. Comments are
+// left out.
+func getDiffTree(root ast.Node) []*diffNode {
+	var nodes, stack []*diffNode
+	ast.Inspect(root, func(node ast.Node) bool {
+		if node == nil {
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			n.size = len(nodes) - n.id
+			setDiffHash(n)
+			return false
+		}
+
+		switch node.(type) {
+		case *ast.CommentGroup, *ast.Comment:
+			return false
+		}
+
+		n := &diffNode{
+			node:  node,
+			typ:   nodeType(node),
+			label: diffLabel(node),
+			id:    len(nodes),
+		}
+
+		if len(stack) > 0 {
+			n.parent = stack[len(stack)-1]
+			n.parent.children = append(n.parent.children, n)
+		}
+
+		nodes = append(nodes, n)
+		stack = append(stack, n)
+		return true
+	})
+
+	return nodes
+}
+
+// setDiffHash sets the height and the hash of a node from its children, so isomorphic subtrees
+// have the same hash.
+func setDiffHash(n *diffNode) {
+	h := fnv.New64a()
+	h.Write([]byte(n.typ))
+	h.Write([]byte{0})
+	h.Write([]byte(n.label))
+	n.height = 1
+	for _, c := range n.children {
+		binary.Write(h, binary.LittleEndian, c.hash)
+		if c.height+1 > n.height {
+			n.height = c.height + 1
+		}
+	}
+
+	n.hash = h.Sum64()
+}
+
+// diffLabel returns the value of the nodes which have one: the name of an identifier, the value of
+// a literal or the operator of an expression or a statement.
+func diffLabel(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.Ident:
+		return n.Name
+	case *ast.BasicLit:
+		return n.Value
+	case *ast.BinaryExpr:
+		return n.Op.String()
+	case *ast.UnaryExpr:
+		return n.Op.String()
+	case *ast.AssignStmt:
+		return n.Tok.String()
+	case *ast.IncDecStmt:
+		return n.Tok.String()
+	case *ast.BranchStmt:
+		return n.Tok.String()
+	case *ast.GenDecl:
+		return n.Tok.String()
+	}
+
+	return ""
+}
+
+// match maps two nodes.
+func (dm *diffMatcher) match(s, d *diffNode) {
+	dm.srcToDst[s] = d
+	dm.dstToSrc[d] = s
+}
+
+// matchSubtree maps two isomorphic subtrees node by node.
+func (dm *diffMatcher) matchSubtree(s, d *diffNode) {
+	dm.match(s, d)
+	for i := range s.children {
+		dm.matchSubtree(s.children[i], d.children[i])
+	}
+}
+
+// dice returns the ratio of the descendants of s and d which are mapped between them.
+func (dm *diffMatcher) dice(s, d *diffNode) float64 {
+	if s.size+d.size == 2 {
+		return 0
+	}
+
+	common := 0
+	for _, n := range dm.src[s.id+1 : s.id+s.size] {
+		if m, ok := dm.srcToDst[n]; ok && d.isDescendant(m) {
+			common++
+		}
+	}
+
+	return 2 * float64(common) / float64(s.size+d.size-2)
+}
+
+// matchTopDown maps the largest isomorphic subtrees, from the highest ones down to diffMinHeight.
+// Subtrees with a single candidate on each side are mapped first; the ambiguous ones are mapped
+// preferring the candidates whose parents are more similar.
+func (dm *diffMatcher) matchTopDown() {
+	maxHeight := dm.src[0].height
+	if dm.dst[0].height > maxHeight {
+		maxHeight = dm.dst[0].height
+	}
+
+	for h := maxHeight; h >= diffMinHeight; h-- {
+		srcByHash := dm.unmatchedByHash(dm.src, dm.srcToDst, h)
+		dstByHash := dm.unmatchedByHash(dm.dst, dm.dstToSrc, h)
+
+		type candidate struct {
+			s, d *diffNode
+			dice float64
+		}
+
+		var ambiguous []candidate
+		for _, s := range dm.src {
+			list, ok := srcByHash[s.hash]
+			if !ok || list[0] != s {
+				continue
+			}
+
+			delete(srcByHash, s.hash)
+			dsts := dstByHash[s.hash]
+			if len(dsts) == 0 {
+				continue
+			}
+
+			if len(list) == 1 && len(dsts) == 1 {
+				dm.matchSubtree(s, dsts[0])
+				continue
+			}
+
+			for _, s := range list {
+				for _, d := range dsts {
+					c := candidate{s: s, d: d}
+					if s.parent != nil && d.parent != nil {
+						c.dice = dm.dice(s.parent, d.parent)
+					}
+
+					ambiguous = append(ambiguous, c)
+				}
+			}
+		}
+
+		sort.SliceStable(ambiguous, func(i, j int) bool { return ambiguous[i].dice > ambiguous[j].dice })
+		for _, c := range ambiguous {
+			_, sMatched := dm.srcToDst[c.s]
+			_, dMatched := dm.dstToSrc[c.d]
+			if !sMatched && !dMatched {
+				dm.matchSubtree(c.s, c.d)
+			}
+		}
+	}
+}
+
+// unmatchedByHash groups the unmapped nodes of a given height by hash, in pre-order.
+func (dm *diffMatcher) unmatchedByHash(nodes []*diffNode, mapped map[*diffNode]*diffNode, height int) map[uint64][]*diffNode {
+	groups := make(map[uint64][]*diffNode)
+	for _, n := range nodes {
+		if _, ok := mapped[n]; ok || n.height != height {
+			continue
+		}
+
+		groups[n.hash] = append(groups[n.hash], n)
+	}
+
+	return groups
+}
+
+// matchBottomUp maps, in post-order, the unmapped containers of the old tree to the container of
+// the same type in the new tree which shares the most mapped descendants, if they are at least
+// diffMinDice of them. The roots are always mapped. The children of every container mapped this way
+// are recovered with matchChildren.
+func (dm *diffMatcher) matchBottomUp() {
+	var postOrder func(n *diffNode)
+	postOrder = func(n *diffNode) {
+		for _, c := range n.children {
+			postOrder(c)
+		}
+
+		if _, ok := dm.srcToDst[n]; ok || len(n.children) == 0 {
+			return
+		}
+
+		if n.parent == nil {
+			if dst := dm.dst[0]; dst.typ == n.typ {
+				if _, ok := dm.dstToSrc[dst]; !ok {
+					dm.match(n, dst)
+					dm.matchChildren(n, dst)
+				}
+			}
+
+			return
+		}
+
+		var best *diffNode
+		bestDice := 0.0
+		for _, d := range dm.candidates(n) {
+			if dice := dm.dice(n, d); dice > bestDice {
+				best, bestDice = d, dice
+			}
+		}
+
+		if best != nil && bestDice >= diffMinDice {
+			dm.match(n, best)
+			dm.matchChildren(n, best)
+		}
+	}
+
+	postOrder(dm.src[0])
+}
+
+// candidates returns the unmapped nodes of the new tree with the type of s which are ancestors of
+// the nodes mapped to the descendants of s.
+func (dm *diffMatcher) candidates(s *diffNode) []*diffNode {
+	var list []*diffNode
+	seen := make(map[*diffNode]bool)
+	for _, n := range dm.src[s.id+1 : s.id+s.size] {
+		m, ok := dm.srcToDst[n]
+		if !ok {
+			continue
+		}
+
+		for d := m.parent; d != nil && !seen[d]; d = d.parent {
+			seen[d] = true
+			if _, ok := dm.dstToSrc[d]; !ok && d.typ == s.typ {
+				list = append(list, d)
+			}
+		}
+	}
+
+	return list
+}
+
+// matchChildren maps the unmapped children of two mapped nodes: first the isomorphic ones, then the
+// ones with the same type and label and finally the ones with the same type. The children of the
+// last two are recovered in turn.
+func (dm *diffMatcher) matchChildren(s, d *diffNode) {
+	same := []func(a, b *diffNode) bool{
+		func(a, b *diffNode) bool { return a.hash == b.hash },
+		func(a, b *diffNode) bool { return a.typ == b.typ && a.label == b.label },
+		func(a, b *diffNode) bool { return a.typ == b.typ },
+	}
+
+	for i, equal := range same {
+		for _, sc := range s.children {
+			if _, ok := dm.srcToDst[sc]; ok {
+				continue
+			}
+
+			for _, dc := range d.children {
+				if _, ok := dm.dstToSrc[dc]; ok || !equal(sc, dc) {
+					continue
+				}
+
+				if i == 0 {
+					dm.matchSubtree(sc, dc)
+				} else {
+					dm.match(sc, dc)
+					dm.matchChildren(sc, dc)
+				}
+
+				break
+			}
+		}
+	}
+}
+
+// editScript returns the operations which turn the old tree into the new one: the roots of the
+// inserted subtrees, the mapped nodes whose label changed, the mapped nodes which changed parent or
+// order between their siblings and the roots of the deleted subtrees.
+func (dm *diffMatcher) editScript(fset *token.FileSet) []*msg.EditOp {
+	var ops []*msg.EditOp
+	for _, d := range dm.dst {
+		s, ok := dm.dstToSrc[d]
+		if !ok {
+			if _, ok := dm.dstToSrc[d.parent]; d.parent == nil || ok {
+				ops = append(ops, &msg.EditOp{Op: "insert", Node: d.typ, New: getDiffNode(fset, d)})
+			}
+
+			continue
+		}
+
+		if s.label != d.label {
+			ops = append(ops, &msg.EditOp{
+				Op:   "update",
+				Node: d.typ,
+				Old:  getDiffNode(fset, s),
+				New:  getDiffNode(fset, d),
+			})
+		}
+
+		if d.parent != nil && (s.parent == nil || dm.srcToDst[s.parent] != d.parent) {
+			ops = append(ops, &msg.EditOp{
+				Op:   "move",
+				Node: d.typ,
+				Old:  getDiffNode(fset, s),
+				New:  getDiffNode(fset, d),
+			})
+		}
+
+		for _, moved := range dm.reordered(s, d) {
+			ops = append(ops, &msg.EditOp{
+				Op:   "move",
+				Node: moved.typ,
+				Old:  getDiffNode(fset, dm.dstToSrc[moved]),
+				New:  getDiffNode(fset, moved),
+			})
+		}
+	}
+
+	for _, s := range dm.src {
+		if _, ok := dm.srcToDst[s]; ok {
+			continue
+		}
+
+		if _, ok := dm.srcToDst[s.parent]; s.parent == nil || ok {
+			ops = append(ops, &msg.EditOp{Op: "delete", Node: s.typ, Old: getDiffNode(fset, s)})
+		}
+	}
+
+	return ops
+}
+
+// reordered returns the children of d, mapped to children of s, which aren't in the longest
+// sequence of children keeping their order between both sides.
+func (dm *diffMatcher) reordered(s, d *diffNode) []*diffNode {
+	index := make(map[*diffNode]int)
+	for i, c := range s.children {
+		index[c] = i
+	}
+
+	// the positions in s of the children of d which stay under the same parent
+	var kept []*diffNode
+	var order []int
+	for _, c := range d.children {
+		if i, ok := index[dm.dstToSrc[c]]; ok {
+			kept = append(kept, c)
+			order = append(order, i)
+		}
+	}
+
+	inLIS := longestIncreasing(order)
+	var moved []*diffNode
+	for i, c := range kept {
+		if !inLIS[i] {
+			moved = append(moved, c)
+		}
+	}
+
+	return moved
+}
+
+// longestIncreasing marks the elements of the longest increasing subsequence of a list.
+func longestIncreasing(list []int) []bool {
+	length := make([]int, len(list))
+	prev := make([]int, len(list))
+	best := -1
+	for i := range list {
+		length[i], prev[i] = 1, -1
+		for j := 0; j < i; j++ {
+			if list[j] < list[i] && length[j]+1 > length[i] {
+				length[i], prev[i] = length[j]+1, j
+			}
+		}
+
+		if best < 0 || length[i] > length[best] {
+			best = i
+		}
+	}
+
+	marks := make([]bool, len(list))
+	for i := best; i >= 0; i = prev[i] {
+		marks[i] = true
+	}
+
+	return marks
+}
+
+// getDiffNode returns the msg.DiffNode of a node.
+func getDiffNode(fset *token.FileSet, n *diffNode) *msg.DiffNode {
+	return &msg.DiffNode{
+		Start: getPosition(fset, n.node.Pos()),
+		End:   getPosition(fset, n.node.End()),
+		Label: n.label,
+	}
+}
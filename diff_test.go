@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetASTDiff(t *testing.T) {
+	old := "package p\n\nfunc a(x int) int {\n\ty := x * 2\n\treturn y + 1\n}\n\nfunc b() {\n\tprintln(\"b\")\n}\n"
+	newContent := "package p\n\nfunc b() {\n\tprintln(\"b\")\n}\n\nfunc a(x int) int {\n\tlog(x)\n\ty := x * 3\n\treturn y + 1\n}\n"
+
+	res := getResponse(&msg.Request{Action: msg.Diff, Old: old, Content: newContent})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Equal(t, []*msg.EditOp{
+		{
+			Op:   "move",
+			Node: "FuncDecl",
+			Old:  &msg.DiffNode{Start: msg.Position{Offset: 11, Line: 3, Column: 1}, End: msg.Position{Offset: 58, Line: 6, Column: 2}},
+			New:  &msg.DiffNode{Start: msg.Position{Offset: 39, Line: 7, Column: 1}, End: msg.Position{Offset: 94, Line: 11, Column: 2}},
+		},
+		{
+			Op:   "insert",
+			Node: "ExprStmt",
+			New:  &msg.DiffNode{Start: msg.Position{Offset: 60, Line: 8, Column: 2}, End: msg.Position{Offset: 66, Line: 8, Column: 8}},
+		},
+		{
+			Op:   "update",
+			Node: "BasicLit",
+			Old:  &msg.DiffNode{Start: msg.Position{Offset: 41, Line: 4, Column: 11}, End: msg.Position{Offset: 42, Line: 4, Column: 12}, Label: "2"},
+			New:  &msg.DiffNode{Start: msg.Position{Offset: 77, Line: 9, Column: 11}, End: msg.Position{Offset: 78, Line: 9, Column: 12}, Label: "3"},
+		},
+	}, res.Edits)
+}
+
+func TestGetASTDiffDelete(t *testing.T) {
+	old := "package p\n\nfunc f() {\n\tg()\n\th()\n}\n"
+	newContent := "package p\n\nfunc f() {\n\th()\n}\n"
+
+	res := getResponse(&msg.Request{Action: msg.Diff, Old: old, Content: newContent})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Equal(t, []*msg.EditOp{
+		{
+			Op:   "delete",
+			Node: "ExprStmt",
+			Old:  &msg.DiffNode{Start: msg.Position{Offset: 23, Line: 4, Column: 2}, End: msg.Position{Offset: 26, Line: 4, Column: 5}},
+		},
+	}, res.Edits)
+
+	res = getResponse(&msg.Request{Action: msg.Diff, Old: newContent, Content: newContent})
+	require.Equal(t, msg.Ok, res.Status)
+	require.Empty(t, res.Edits)
+}
+
+func TestGetASTDiffErrors(t *testing.T) {
+	res := getResponse(&msg.Request{Action: msg.Diff, Old: "package p\n", Content: "package p\n\nfunc {\n"})
+	require.Equal(t, msg.Error, res.Status)
+	require.NotEmpty(t, res.Errors)
+	require.NotEmpty(t, res.Edits)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"go/token"
+	"strings"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+)
+
+// getDirectives replies a msg.Directives request with the //go: directives and the build constraint
+// lines of the content. The build constraint of the file is evaluated against m.Tags. Malformed
+// build constraints are replied as errors with msg.Error status.
+func getDirectives(m *msg.Request) *msg.Response {
+	res := newResponse()
+	fset := token.NewFileSet()
+	tree, ok := parseFile(res, fset, m.Content)
+	if !ok {
+		return res
+	}
+
+	var goBuild constraint.Expr
+	var plusBuild []constraint.Expr
+	directives := &msg.FileDirectives{}
+	for _, group := range tree.Comments {
+		for _, c := range group.List {
+			d := getDirective(fset, c)
+			if d == nil {
+				continue
+			}
+
+			directives.List = append(directives.List, d)
+			if !constraint.IsGoBuild(c.Text) && !constraint.IsPlusBuild(c.Text) {
+				continue
+			}
+
+			expr, err := constraint.Parse(c.Text)
+			if err != nil {
+				res.Status = msg.Error
+				res.Errors = append(res.Errors, fmt.Sprintf("%s: %v", fset.Position(c.Pos()), err))
+				continue
+			}
+
+			d.Constraint = getConstraint(expr)
+
+			// only the lines before the package clause constrain the file
+			if c.Pos() > tree.Package {
+				continue
+			}
+
+			if constraint.IsGoBuild(c.Text) {
+				if goBuild == nil {
+					goBuild = expr
+				}
+			} else {
+				plusBuild = append(plusBuild, expr)
+			}
+		}
+	}
+
+	expr := goBuild
+	if expr == nil {
+		for _, e := range plusBuild {
+			if expr == nil {
+				expr = e
+			} else {
+				expr = &constraint.AndExpr{X: expr, Y: e}
+			}
+		}
+	}
+
+	directives.Satisfied = true
+	if expr != nil {
+		tags := make(map[string]bool)
+		for _, tag := range m.Tags {
+			tags[tag] = true
+		}
+
+		directives.Constraint = getConstraint(expr)
+		directives.Satisfied = expr.Eval(func(tag string) bool { return tags[tag] })
+	}
+
+	res.Directives = directives
+	return res
+}
+
+// getDirective returns the msg.Directive of a //go: directive or a // +build comment. It returns nil
+// for any other comment.
+func getDirective(fset *token.FileSet, c *ast.Comment) *msg.Directive {
+	var text string
+	switch {
+	case strings.HasPrefix(c.Text, "//go:"):
+		text = c.Text[len("//"):]
+	case constraint.IsPlusBuild(c.Text):
+		text = strings.TrimSpace(c.Text[len("//"):])
+	default:
+		return nil
+	}
+
+	fields := strings.Fields(text)
+	d := &msg.Directive{
+		Name:  fields[0],
+		Text:  c.Text,
+		Start: getPosition(fset, c.Pos()),
+	}
+
+	if len(fields) > 1 {
+		d.Args = fields[1:]
+	}
+
+	return d
+}
+
+// getConstraint converts a constraint.Expr in a msg.Constraint tree.
+func getConstraint(expr constraint.Expr) *msg.Constraint {
+	switch e := expr.(type) {
+	case *constraint.AndExpr:
+		return &msg.Constraint{Op: "and", X: getConstraint(e.X), Y: getConstraint(e.Y)}
+	case *constraint.OrExpr:
+		return &msg.Constraint{Op: "or", X: getConstraint(e.X), Y: getConstraint(e.Y)}
+	case *constraint.NotExpr:
+		return &msg.Constraint{Op: "not", X: getConstraint(e.X)}
+	case *constraint.TagExpr:
+		return &msg.Constraint{Op: "tag", Tag: e.Tag}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDirectives(t *testing.T) {
+	source := `//go:build linux && (amd64 || !cgo)
+// +build linux
+
+//go:generate stringer -type=Kind
+
+package foo
+
+import _ "embed"
+
+//go:embed hello.txt
+var hello string
+
+//go:noinline
+func f() {}
+
+//go:linkname now time.now
+func now() (int64, int32)
+`
+	linux := &msg.Constraint{Op: "tag", Tag: "linux"}
+	goBuild := &msg.Constraint{
+		Op: "and",
+		X:  linux,
+		Y: &msg.Constraint{
+			Op: "or",
+			X:  &msg.Constraint{Op: "tag", Tag: "amd64"},
+			Y:  &msg.Constraint{Op: "not", X: &msg.Constraint{Op: "tag", Tag: "cgo"}},
+		},
+	}
+
+	res := getResponse(&msg.Request{Action: msg.Directives, Content: source, Tags: []string{"linux", "cgo"}})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Equal(t, []*msg.Directive{
+		{Name: "go:build", Args: []string{"linux", "&&", "(amd64", "||", "!cgo)"}, Text: "//go:build linux && (amd64 || !cgo)", Start: msg.Position{Offset: 0, Line: 1, Column: 1}, Constraint: goBuild},
+		{Name: "+build", Args: []string{"linux"}, Text: "// +build linux", Start: msg.Position{Offset: 36, Line: 2, Column: 1}, Constraint: linux},
+		{Name: "go:generate", Args: []string{"stringer", "-type=Kind"}, Text: "//go:generate stringer -type=Kind", Start: msg.Position{Offset: 53, Line: 4, Column: 1}},
+		{Name: "go:embed", Args: []string{"hello.txt"}, Text: "//go:embed hello.txt", Start: msg.Position{Offset: 119, Line: 10, Column: 1}},
+		{Name: "go:noinline", Text: "//go:noinline", Start: msg.Position{Offset: 158, Line: 13, Column: 1}},
+		{Name: "go:linkname", Args: []string{"now", "time.now"}, Text: "//go:linkname now time.now", Start: msg.Position{Offset: 185, Line: 16, Column: 1}},
+	}, res.Directives.List)
+	require.Equal(t, goBuild, res.Directives.Constraint)
+	require.False(t, res.Directives.Satisfied)
+
+	res = getResponse(&msg.Request{Action: msg.Directives, Content: source, Tags: []string{"linux", "amd64", "cgo"}})
+	require.True(t, res.Directives.Satisfied)
+}
+
+func TestGetDirectivesPlusBuild(t *testing.T) {
+	source := "// +build linux darwin\n// +build !386\n\npackage foo\n"
+	res := getResponse(&msg.Request{Action: msg.Directives, Content: source, Tags: []string{"darwin"}})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Equal(t, &msg.Constraint{
+		Op: "and",
+		X: &msg.Constraint{
+			Op: "or",
+			X:  &msg.Constraint{Op: "tag", Tag: "linux"},
+			Y:  &msg.Constraint{Op: "tag", Tag: "darwin"},
+		},
+		Y: &msg.Constraint{Op: "not", X: &msg.Constraint{Op: "tag", Tag: "386"}},
+	}, res.Directives.Constraint)
+	require.True(t, res.Directives.Satisfied)
+}
+
+func TestGetDirectivesErrors(t *testing.T) {
+	res := getResponse(&msg.Request{Action: msg.Directives, Content: "//go:build linux &&\n\npackage foo\n"})
+	require.Equal(t, msg.Error, res.Status)
+	require.Equal(t, []string{"source.go:1:1: unexpected end of expression"}, res.Errors)
+	require.Len(t, res.Directives.List, 1)
+	require.Nil(t, res.Directives.Constraint)
+	require.True(t, res.Directives.Satisfied)
+}
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/src-d/babelfish-go-driver/msg"
 
@@ -13,6 +14,7 @@ import (
 
 type options struct {
 	File            string `short:"f" long:"file" description:"Source code file" required:"true"`
+	Action          string `short:"a" long:"action" description:"Request action" default:"ParseAST"`
 	Language        string `short:"l" long:"language" description:"File's source code language" default:""`
 	LanguageVersion string `short:"v" long:"version" description:"File's source code language version" default:""`
 }
@@ -39,10 +41,11 @@ func main() {
 	}
 
 	req := &msg.Request{
-		Action:          msg.ParseAst,
+		Action:          opt.Action,
 		Language:        opt.Language,
 		LanguageVersion: opt.LanguageVersion,
 		Content:         string(source),
+		Archive:         strings.HasSuffix(opt.File, ".txtar"),
 	}
 
 	enc := json.NewEncoder(os.Stdout)
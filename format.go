@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"go/token"
+	"strings"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// getFormat replies a msg.Format request with the content formatted as gofmt does. Syntax errors are
+// replied in the same way as in msg.ParseAst and no source is returned.
+func getFormat(m *msg.Request) *msg.Response {
+	res := newResponse()
+	fset := token.NewFileSet()
+	tree, ok := parseFile(res, fset, m.Content)
+	if !ok || res.Status != msg.Ok {
+		return res
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, tree); err != nil {
+		res.Status = msg.Fatal
+		res.Errors = []string{err.Error()}
+		return res
+	}
+
+	formatted := buf.String()
+	res.Format = &msg.Formatted{
+		Source:  formatted,
+		Changed: formatted != m.Content,
+	}
+
+	if res.Format.Changed {
+		diff, err := getDiff(m.Content, formatted)
+		if err != nil {
+			res.Status = msg.Fatal
+			res.Errors = []string{err.Error()}
+			return res
+		}
+
+		res.Format.Diff = diff
+	}
+
+	return res
+}
+
+// getDiff builds the unified diff between the old and new source.
+func getDiff(old, new string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(old),
+		B:        splitLines(new),
+		FromFile: "source.go",
+		ToFile:   "source.go",
+		Context:  3,
+	})
+}
+
+// splitLines splits a source in lines keeping the line endings. A missing final line ending is added
+// to keep the diff output readable.
+func splitLines(source string) []string {
+	lines := strings.SplitAfter(source, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+
+	lines[len(lines)-1] += "\n"
+	return lines
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		status  string
+		want    *msg.Formatted
+	}{
+		{
+			name:    "formatted",
+			content: "package main\n\nfunc main() {}\n",
+			status:  msg.Ok,
+			want:    &msg.Formatted{Source: "package main\n\nfunc main() {}\n"},
+		},
+		{
+			name:    "unformatted",
+			content: "package main\nimport (\"os\"\n\"fmt\")\nfunc main() { fmt.Println(os.Args) }\n",
+			status:  msg.Ok,
+			want: &msg.Formatted{
+				Source:  "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\nfunc main() { fmt.Println(os.Args) }\n",
+				Changed: true,
+				Diff:    "--- source.go\n+++ source.go\n@@ -1,4 +1,8 @@\n package main\n-import (\"os\"\n-\"fmt\")\n+\n+import (\n+\t\"fmt\"\n+\t\"os\"\n+)\n+\n func main() { fmt.Println(os.Args) }\n",
+			},
+		},
+		{
+			name:    "syntax error",
+			content: "package main\nfunc main() {\n",
+			status:  msg.Error,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := getResponse(&msg.Request{Action: msg.Format, Content: test.content})
+			require.Equal(t, test.status, got.Status)
+			require.Equal(t, test.want, got.Format)
+			require.Nil(t, got.AST)
+			if test.status == msg.Error {
+				require.NotEmpty(t, got.Errors)
+			}
+		})
+	}
+}
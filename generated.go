@@ -0,0 +1,55 @@
+package main
+
+import (
+	"go/scanner"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+const (
+	generatedPrefix = "// Code generated "
+	generatedSuffix = " DO NOT EDIT."
+)
+
+// generator looks for the comment line which marks generated code, as ast.IsGenerated does, in the
+// comments before the first token of a source. So it works for whole files, where the first token
+// is the package clause, and for snippets. It returns the generator named in the comment, if any.
+func generator(source string) (string, bool) {
+	src := []byte(source)
+	var s scanner.Scanner
+	s.Init(token.NewFileSet().AddFile("", -1, len(src)), src, nil, scanner.ScanComments)
+	for {
+		_, tok, lit := s.Scan()
+		if tok != token.COMMENT {
+			return "", false
+		}
+
+		if !strings.Contains(lit, generatedPrefix) {
+			continue
+		}
+
+		for _, line := range strings.Split(lit, "\n") {
+			if !strings.HasPrefix(line, generatedPrefix) || !strings.HasSuffix(line, generatedSuffix) {
+				continue
+			}
+
+			return generatorName(line[len(generatedPrefix) : len(line)-len(generatedSuffix)]), true
+		}
+	}
+}
+
+// generatorName extracts the generator from the text between "Code generated" and "DO NOT EDIT.",
+// i.e. `by protoc-gen-go.` or `by "stringer -type=Kind";`.
+func generatorName(text string) string {
+	if !strings.HasPrefix(text, "by ") {
+		return ""
+	}
+
+	name := strings.TrimRight(strings.TrimPrefix(text, "by "), ".;, ")
+	if unquoted, err := strconv.Unquote(name); err == nil {
+		return unquoted
+	}
+
+	return name
+}
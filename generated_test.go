@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		generated bool
+		generator string
+	}{
+		{"protoc", "// Code generated by protoc-gen-go. DO NOT EDIT.\n// source: foo.proto\n\npackage foo\n", true, "protoc-gen-go"},
+		{"quoted", "// Code generated by \"stringer -type=Kind\"; DO NOT EDIT.\n\npackage foo\n", true, "stringer -type=Kind"},
+		{"anonymous", "// Code generated for tests. DO NOT EDIT.\npackage foo\n", true, ""},
+		{"block", "/*\n// Code generated by hand. DO NOT EDIT.\n*/\npackage foo\n", true, "hand"},
+		{"second group", "// Copyright.\n\n// Code generated by gen. DO NOT EDIT.\n\npackage foo\n", true, "gen"},
+		{"after package", "package foo\n\n// Code generated by gen. DO NOT EDIT.\n", false, ""},
+		{"not a line", "// This is not Code generated by gen. DO NOT EDIT.\npackage foo\n", false, ""},
+		{"missing period", "// Code generated by gen. DO NOT EDIT\npackage foo\n", false, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			generator, generated := generator(test.source)
+			require.Equal(t, test.generated, generated)
+			require.Equal(t, test.generator, generator)
+		})
+	}
+}
+
+func TestGetResponseGenerated(t *testing.T) {
+	header := "// Code generated by gen. DO NOT EDIT.\n\n"
+	tests := []*msg.Request{
+		{Action: msg.ParseAst, Content: header + "package foo\n"},
+		{Action: msg.ParseAst, Mode: msg.ModeExpr, Content: header + "a + b"},
+		{Action: msg.ParseAst, Mode: msg.ModeStmts, Content: header + "x := 1"},
+		{Action: msg.ParseAst, Mode: msg.ModeDecls, Content: header + "func F() {}"},
+	}
+
+	for _, req := range tests {
+		t.Run(req.Mode, func(t *testing.T) {
+			res := getResponse(req)
+			require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+			require.True(t, res.Generated)
+			require.Equal(t, "gen", res.Generator)
+		})
+	}
+
+	res := getResponse(&msg.Request{
+		Action: msg.ParsePackage,
+		Files: []*msg.SourceFile{
+			{Name: "a.go", Content: "package foo\n"},
+			{Name: "b.go", Content: header + "package foo\n"},
+		},
+	})
+	require.False(t, res.Generated)
+	require.False(t, res.Package.Files[0].Generated)
+	require.True(t, res.Package.Files[1].Generated)
+}
+
+func TestGetResponseNotGoContent(t *testing.T) {
+	header := "// Code generated by gen. DO NOT EDIT.\n\n"
+	tests := []*msg.Request{
+		{Action: msg.ParseGoMod, Content: header + "module foo\n"},
+		{Action: msg.ParseGoWork, Content: header + "go 1.21\n"},
+		{Action: msg.ParseTemplate, Content: header + "{{.Name}}\n"},
+		{Action: msg.ParseAst, Content: header + "-- a.go --\npackage a\n", Archive: true},
+	}
+
+	for _, req := range tests {
+		t.Run(req.Action, func(t *testing.T) {
+			res := getResponse(req)
+			require.NotEqual(t, msg.Fatal, res.Status, "%v", res.Errors)
+			require.False(t, res.Generated)
+			require.Empty(t, res.Generator)
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"strconv"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+)
+
+// getHighlight replies a msg.Highlight request with the tokens of the content classified for syntax
+// highlighting. Keywords, literals and comments are classified by the scanner, and identifiers by
+// their place in the AST and the objects the parser resolves, since there is no type checking:
+// identifiers which can't be classified, like variables, and operators are left out.
+func getHighlight(m *msg.Request) *msg.Response {
+	res := newResponse()
+	fset := token.NewFileSet()
+	tree, ok := parseFile(res, fset, m.Content)
+	if !ok {
+		return res
+	}
+
+	file := fset.File(tree.FileStart)
+	idents := classifyIdents(tree)
+	kinds := make(map[int]string, len(idents))
+	for ident, kind := range idents {
+		kinds[file.Offset(ident.Pos())] = kind
+	}
+
+	res.Highlight = []*msg.HighlightSpan{}
+	src := []byte(m.Content)
+	scanned := token.NewFileSet().AddFile(file.Name(), -1, len(src))
+	var s scanner.Scanner
+	s.Init(scanned, src, nil, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		var kind string
+		switch {
+		case tok.IsKeyword():
+			kind = "keyword"
+		case tok == token.STRING || tok == token.CHAR:
+			kind = "string"
+			// the scanner strips carriage returns from the literal of raw strings
+			if lit[0] == '`' {
+				lit = m.Content[scanned.Offset(pos):tokenEnd(src, scanned.Offset(pos), lit)]
+			}
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			kind = "number"
+		case tok == token.COMMENT:
+			kind = "comment"
+			// the scanner strips carriage returns from the literal of comments
+			lit = m.Content[scanned.Offset(pos):tokenEnd(src, scanned.Offset(pos), lit)]
+		case tok == token.IDENT:
+			kind = kinds[scanned.Offset(pos)]
+		}
+
+		if kind == "" {
+			continue
+		}
+
+		offset := scanned.Offset(pos)
+		res.Highlight = append(res.Highlight, &msg.HighlightSpan{
+			Kind:  kind,
+			Start: getOffsetPosition(file, offset),
+			End:   getOffsetPosition(file, offset+len(lit)),
+		})
+	}
+
+	return res
+}
+
+// tokenEnd returns the end offset of a comment or a raw string whose literal, without carriage
+// returns, is lit.
+func tokenEnd(src []byte, offset int, lit string) int {
+	end := offset
+	for n := 0; n < len(lit); end++ {
+		if src[end] != '\r' || lit[n] == '\r' {
+			n++
+		}
+	}
+
+	return end
+}
+
+// classifyIdents returns the highlighting kind of the identifiers of a file which can be told from
+// the AST. Nodes are visited before their children, and the first kind given to an identifier is
+// kept, so a call or a type context decides over the generic rules of its selectors.
+func classifyIdents(tree *ast.File) map[*ast.Ident]string {
+	kinds := make(map[*ast.Ident]string)
+	mark := func(ident *ast.Ident, kind string) {
+		if ident == nil || ident.Name == "_" {
+			return
+		}
+
+		if _, ok := kinds[ident]; !ok {
+			kinds[ident] = kind
+		}
+	}
+
+	packages := make(map[string]bool)
+	for _, spec := range tree.Imports {
+		if path, err := strconv.Unquote(spec.Path.Value); err == nil {
+			packages[importName(tree, path)] = true
+		}
+	}
+
+	// isPackage reports whether an expression is an identifier referring to an imported package
+	isPackage := func(expr ast.Expr) bool {
+		ident, ok := expr.(*ast.Ident)
+		return ok && ident.Obj == nil && packages[ident.Name]
+	}
+
+	var markType func(expr ast.Expr)
+	var markFields func(list *ast.FieldList, kind string)
+	markType = func(expr ast.Expr) {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			mark(e, "type")
+		case *ast.SelectorExpr:
+			if isPackage(e.X) {
+				mark(e.X.(*ast.Ident), "package")
+			}
+
+			mark(e.Sel, "type")
+		case *ast.ParenExpr:
+			markType(e.X)
+		case *ast.StarExpr:
+			markType(e.X)
+		case *ast.UnaryExpr:
+			markType(e.X)
+		case *ast.BinaryExpr:
+			markType(e.X)
+			markType(e.Y)
+		case *ast.Ellipsis:
+			markType(e.Elt)
+		case *ast.ArrayType:
+			markType(e.Elt)
+		case *ast.MapType:
+			markType(e.Key)
+			markType(e.Value)
+		case *ast.ChanType:
+			markType(e.Value)
+		case *ast.IndexExpr:
+			markType(e.X)
+			markType(e.Index)
+		case *ast.IndexListExpr:
+			markType(e.X)
+			for _, index := range e.Indices {
+				markType(index)
+			}
+		case *ast.FuncType:
+			markFields(e.TypeParams, "type")
+			markFields(e.Params, "parameter")
+			markFields(e.Results, "parameter")
+		case *ast.StructType:
+			markFields(e.Fields, "field")
+		case *ast.InterfaceType:
+			markFields(e.Methods, "method")
+		}
+	}
+
+	markFields = func(list *ast.FieldList, kind string) {
+		if list == nil {
+			return
+		}
+
+		for _, field := range list.List {
+			for _, name := range field.Names {
+				mark(name, kind)
+			}
+
+			markType(field.Type)
+		}
+	}
+
+	ast.Inspect(tree, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.File:
+			mark(n.Name, "package")
+		case *ast.ImportSpec:
+			mark(n.Name, "package")
+		case *ast.FuncDecl:
+			if n.Recv != nil {
+				mark(n.Name, "method")
+				markFields(n.Recv, "parameter")
+			} else {
+				mark(n.Name, "function")
+			}
+		case *ast.TypeSpec:
+			mark(n.Name, "type")
+			markFields(n.TypeParams, "type")
+			markType(n.Type)
+		case *ast.ValueSpec:
+			markType(n.Type)
+		case *ast.Field:
+			markType(n.Type)
+		case *ast.FuncType, *ast.StructType, *ast.InterfaceType, *ast.ArrayType, *ast.MapType,
+			*ast.ChanType:
+			markType(n.(ast.Expr))
+		case *ast.CompositeLit:
+			markType(n.Type)
+			if _, ok := n.Type.(*ast.MapType); ok {
+				break
+			}
+
+			for _, elt := range n.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					continue
+				}
+
+				// keys resolved by the parser are variables, not fields
+				if key, ok := kv.Key.(*ast.Ident); ok && key.Obj == nil {
+					mark(key, "field")
+				}
+			}
+		case *ast.TypeAssertExpr:
+			markType(n.Type)
+		case *ast.CallExpr:
+			markCall(n, mark, markType, isPackage)
+		case *ast.SelectorExpr:
+			if isPackage(n.X) {
+				mark(n.X.(*ast.Ident), "package")
+			} else {
+				mark(n.Sel, "field")
+			}
+		case *ast.LabeledStmt:
+			mark(n.Label, "label")
+		case *ast.BranchStmt:
+			mark(n.Label, "label")
+		case *ast.Ident:
+			markObject(n, mark)
+		}
+
+		return true
+	})
+
+	return kinds
+}
+
+// markCall classifies the function of a call: the name of a function, a method, or a type in
+// conversions and in the first argument of make and new.
+func markCall(call *ast.CallExpr, mark func(*ast.Ident, string), markType func(ast.Expr),
+	isPackage func(ast.Expr) bool) {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		if fun.Obj != nil {
+			if fun.Obj.Kind == ast.Typ {
+				mark(fun, "type")
+			} else if fun.Obj.Kind == ast.Fun {
+				mark(fun, "function")
+			}
+
+			return
+		}
+
+		if _, ok := types.Universe.Lookup(fun.Name).(*types.TypeName); ok {
+			mark(fun, "type")
+			return
+		}
+
+		mark(fun, "function")
+		if (fun.Name == "make" || fun.Name == "new") && len(call.Args) > 0 {
+			markType(call.Args[0])
+		}
+	case *ast.SelectorExpr:
+		if isPackage(fun.X) {
+			mark(fun.X.(*ast.Ident), "package")
+			mark(fun.Sel, "function")
+		} else {
+			mark(fun.Sel, "method")
+		}
+	case *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType,
+		*ast.StructType:
+		markType(fun)
+	case *ast.ParenExpr:
+		// conversions to pointer types, like (*T)(x)
+		if star, ok := fun.X.(*ast.StarExpr); ok {
+			markType(star)
+		}
+	}
+}
+
+// markObject classifies an identifier by the object the parser resolved it to, if any: parameters,
+// types, functions and labels.
+func markObject(ident *ast.Ident, mark func(*ast.Ident, string)) {
+	if ident.Obj == nil {
+		return
+	}
+
+	switch ident.Obj.Kind {
+	case ast.Typ:
+		mark(ident, "type")
+	case ast.Fun:
+		mark(ident, "function")
+	case ast.Lbl:
+		mark(ident, "label")
+	case ast.Var:
+		if _, ok := ident.Obj.Decl.(*ast.Field); ok {
+			mark(ident, "parameter")
+		}
+	}
+}
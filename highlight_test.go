@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetHighlight(t *testing.T) {
+	content := `package foo
+
+import "strings"
+
+// T is a type.
+type T struct{ Name string }
+
+func (t *T) Join(parts []string, n int) string {
+loop:
+	for i := range parts {
+		if i > n {
+			break loop
+		}
+	}
+	s := strings.Join(parts, t.Name)
+	return T{Name: s}.Upper(1.5) + string(rune('x'))
+}
+`
+	res := getResponse(&msg.Request{Action: msg.Highlight, Content: content})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+
+	var spans [][2]string
+	for _, span := range res.Highlight {
+		spans = append(spans, [2]string{span.Kind, content[span.Start.Offset:span.End.Offset]})
+	}
+
+	require.Equal(t, [][2]string{
+		{"keyword", "package"}, {"package", "foo"},
+		{"keyword", "import"}, {"string", `"strings"`},
+		{"comment", "// T is a type."},
+		{"keyword", "type"}, {"type", "T"}, {"keyword", "struct"}, {"field", "Name"}, {"type", "string"},
+		{"keyword", "func"}, {"parameter", "t"}, {"type", "T"}, {"method", "Join"},
+		{"parameter", "parts"}, {"type", "string"}, {"parameter", "n"}, {"type", "int"}, {"type", "string"},
+		{"label", "loop"},
+		{"keyword", "for"}, {"keyword", "range"}, {"parameter", "parts"},
+		{"keyword", "if"}, {"parameter", "n"},
+		{"keyword", "break"}, {"label", "loop"},
+		{"package", "strings"}, {"function", "Join"}, {"parameter", "parts"}, {"parameter", "t"}, {"field", "Name"},
+		{"keyword", "return"}, {"type", "T"}, {"field", "Name"}, {"method", "Upper"}, {"number", "1.5"},
+		{"type", "string"}, {"type", "rune"}, {"string", "'x'"},
+	}, spans)
+
+	require.Equal(t, msg.Position{Offset: 31, Line: 5, Column: 1}, res.Highlight[4].Start)
+	require.Equal(t, msg.Position{Offset: 46, Line: 5, Column: 16}, res.Highlight[4].End)
+}
+
+func TestGetHighlightCarriageReturns(t *testing.T) {
+	content := "package foo\r\n\r\n/* a\r\nb */\r\n// c\r\nconst s = `a\r\nb`\r\n"
+	res := getResponse(&msg.Request{Action: msg.Highlight, Content: content})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Len(t, res.Highlight, 6)
+	require.Equal(t, "/* a\r\nb */", content[res.Highlight[2].Start.Offset:res.Highlight[2].End.Offset])
+	require.Equal(t, "// c", content[res.Highlight[3].Start.Offset:res.Highlight[3].End.Offset])
+	require.Equal(t, "`a\r\nb`", content[res.Highlight[5].Start.Offset:res.Highlight[5].End.Offset])
+}
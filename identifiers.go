@@ -0,0 +1,75 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+	"unicode"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+)
+
+// getIdentifiers splits every identifier of the nodes in sub-tokens and counts the normalized ones.
+func getIdentifiers(fset *token.FileSet, nodes ...ast.Node) *msg.Identifiers {
+	idents := &msg.Identifiers{Bag: make(map[string]int)}
+	for _, n := range nodes {
+		inspectIdentifiers(fset, n, idents)
+	}
+
+	return idents
+}
+
+// inspectIdentifiers adds the identifiers of a node to idents.
+func inspectIdentifiers(fset *token.FileSet, n ast.Node, idents *msg.Identifiers) {
+	ast.Inspect(n, func(node ast.Node) bool {
+		id, ok := node.(*ast.Ident)
+		if !ok {
+			return true
+		}
+
+		tokens := splitIdentifier(id.Name)
+		var normalized []string
+		for _, t := range tokens {
+			t = strings.ToLower(t)
+			normalized = append(normalized, t)
+			idents.Bag[t]++
+		}
+
+		idents.List = append(idents.List, &msg.Identifier{
+			Name:       id.Name,
+			Start:      getPosition(fset, id.Pos()),
+			Tokens:     tokens,
+			Normalized: normalized,
+		})
+
+		return true
+	})
+}
+
+// splitIdentifier splits a name in sub-tokens at underscores and at case and digit boundaries.
+// An acronym is kept together and the last upper case letter before a lower case one starts a new
+// token, so HTTPServer is split in HTTP and Server.
+func splitIdentifier(name string) []string {
+	var tokens []string
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, part := range parts {
+		runes := []rune(part)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			prev, cur := runes[i-1], runes[i]
+			if unicode.IsLower(prev) && unicode.IsUpper(cur) ||
+				unicode.IsDigit(prev) != unicode.IsDigit(cur) ||
+				unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				tokens = append(tokens, string(runes[start:i]))
+				start = i
+			}
+		}
+
+		tokens = append(tokens, string(runes[start:]))
+	}
+
+	return tokens
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitIdentifier(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []string
+	}{
+		{"x", []string{"x"}},
+		{"camelCase", []string{"camel", "Case"}},
+		{"snake_case_name", []string{"snake", "case", "name"}},
+		{"HTTPServer", []string{"HTTP", "Server"}},
+		{"parseHTTPRequest2", []string{"parse", "HTTP", "Request", "2"}},
+		{"ServeHTTP", []string{"Serve", "HTTP"}},
+		{"utf8String", []string{"utf", "8", "String"}},
+		{"_private__name_", []string{"private", "name"}},
+		{"ÁrbolÑandú", []string{"Árbol", "Ñandú"}},
+		{"_", nil},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.tokens, splitIdentifier(test.name), test.name)
+	}
+}
+
+func TestGetParseASTIdentifiers(t *testing.T) {
+	source := "package foo_bar\n\nvar HTTPServer2, _ = newServer()\n"
+	res := getResponse(&msg.Request{Content: source, Identifiers: true})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.NotNil(t, res.AST)
+	require.Equal(t, &msg.Identifiers{
+		List: []*msg.Identifier{
+			{
+				Name:       "foo_bar",
+				Start:      msg.Position{Offset: 8, Line: 1, Column: 9},
+				Tokens:     []string{"foo", "bar"},
+				Normalized: []string{"foo", "bar"},
+			},
+			{
+				Name:       "HTTPServer2",
+				Start:      msg.Position{Offset: 21, Line: 3, Column: 5},
+				Tokens:     []string{"HTTP", "Server", "2"},
+				Normalized: []string{"http", "server", "2"},
+			},
+			{
+				Name:  "_",
+				Start: msg.Position{Offset: 34, Line: 3, Column: 18},
+			},
+			{
+				Name:       "newServer",
+				Start:      msg.Position{Offset: 38, Line: 3, Column: 22},
+				Tokens:     []string{"new", "Server"},
+				Normalized: []string{"new", "server"},
+			},
+		},
+		Bag: map[string]int{"foo": 1, "bar": 1, "http": 1, "server": 2, "2": 1, "new": 1},
+	}, res.Identifiers)
+
+	res = getResponse(&msg.Request{Content: source})
+	require.Nil(t, res.Identifiers)
+}
+
+func TestGetSnippetIdentifiers(t *testing.T) {
+	res := getResponse(&msg.Request{Mode: msg.ModeExpr, Content: "fooBar + x", Identifiers: true})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Len(t, res.Identifiers.List, 2)
+	require.Equal(t, msg.Position{Offset: 0, Line: 1, Column: 1}, res.Identifiers.List[0].Start)
+	require.Equal(t, msg.Position{Offset: 9, Line: 1, Column: 10}, res.Identifiers.List[1].Start)
+
+	res = getResponse(&msg.Request{Mode: msg.ModeStmts, Content: "a := 1\nfooBar(a)", Identifiers: true})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Len(t, res.Identifiers.List, 3)
+	require.Equal(t, "fooBar", res.Identifiers.List[1].Name)
+	require.Equal(t, msg.Position{Offset: 7, Line: 2, Column: 1}, res.Identifiers.List[1].Start)
+	require.Equal(t, map[string]int{"a": 2, "foo": 1, "bar": 1}, res.Identifiers.Bag)
+
+	res = getResponse(&msg.Request{Mode: msg.ModeDecls, Content: "type T int\n\nvar v T", Identifiers: true})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Len(t, res.Identifiers.List, 4)
+	require.Equal(t, msg.Position{Offset: 16, Line: 3, Column: 5}, res.Identifiers.List[2].Start)
+}
+
+func TestIdentifiersRejected(t *testing.T) {
+	res := getResponse(&msg.Request{Action: msg.ParsePackage, Content: "package foo\n", Identifiers: true})
+	require.Equal(t, msg.Fatal, res.Status)
+	require.Equal(t, []string{`action "ParsePackage" doesn't accept the identifiers option`}, res.Errors)
+
+	res = getResponse(&msg.Request{Content: "-- a.go --\npackage a\n", Archive: true, Identifiers: true})
+	require.Equal(t, msg.Fatal, res.Status)
+	require.Equal(t, []string{"archives don't accept the identifiers option"}, res.Errors)
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// getImports replies a msg.Imports request with the content after applying the import edits of
+// m.Imports, formatted as gofmt does, and the edits which changed it. Imports are edited with
+// astutil, so rewriting a path doesn't rename the references to the package. Whether an import is
+// used is told by the selectors on its name, guessed from the path when it has no alias, as
+// importUsed does. Syntax errors are replied in the same way as in msg.ParseAst and no source is
+// returned.
+func getImports(m *msg.Request) *msg.Response {
+	res := newResponse()
+	if err := checkImportOptions(m.Imports); err != nil {
+		res.Status = msg.Fatal
+		res.Errors = []string{err.Error()}
+		return res
+	}
+
+	fset := token.NewFileSet()
+	tree, ok := parseFile(res, fset, m.Content)
+	if !ok || res.Status != msg.Ok {
+		return res
+	}
+
+	opts := m.Imports
+	fixed := &msg.FixedImports{Changes: []*msg.ImportChange{}}
+	for _, r := range opts.Rewrite {
+		if astutil.RewriteImport(fset, tree, r.Old, r.New) {
+			fixed.Changes = append(fixed.Changes, &msg.ImportChange{Change: "rewritten", Path: r.New, Old: r.Old})
+		}
+	}
+
+	if opts.DeleteUnused {
+		// deleting an import updates tree.Imports
+		specs := append([]*ast.ImportSpec(nil), tree.Imports...)
+		for _, spec := range specs {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil || importUsed(tree, spec, path) {
+				continue
+			}
+
+			var name string
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+
+			// astutil leaves the comments of the deleted import behind
+			removeComments(tree, spec.Doc, spec.Comment)
+			if astutil.DeleteNamedImport(fset, tree, name, path) {
+				fixed.Changes = append(fixed.Changes, &msg.ImportChange{Change: "deleted", Name: name, Path: path})
+			}
+		}
+	}
+
+	for _, spec := range opts.Add {
+		if astutil.AddNamedImport(fset, tree, spec.Name, spec.Path) {
+			fixed.Changes = append(fixed.Changes, &msg.ImportChange{Change: "added", Name: spec.Name, Path: spec.Path})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, tree); err != nil {
+		res.Status = msg.Fatal
+		res.Errors = []string{err.Error()}
+		return res
+	}
+
+	fixed.Source = buf.String()
+	if opts.Group {
+		grouped, err := groupImports(fixed.Source)
+		if err != nil {
+			res.Status = msg.Fatal
+			res.Errors = []string{err.Error()}
+			return res
+		}
+
+		if grouped != fixed.Source {
+			fixed.Source = grouped
+			fixed.Changes = append(fixed.Changes, &msg.ImportChange{Change: "grouped"})
+		}
+	}
+
+	res.Imports = fixed
+	return res
+}
+
+// importUsed reports whether a selector of a file is rooted at the name of an import. Unresolved
+// identifiers are matched against the alias of the import or, without one, both the last element of
+// the path and the name assumedImportName guesses, since the package name isn't known. Blank and dot
+// imports are always used.
+func importUsed(tree *ast.File, spec *ast.ImportSpec, path string) bool {
+	names := map[string]bool{path[strings.LastIndex(path, "/")+1:]: true, assumedImportName(path): true}
+	if spec.Name != nil {
+		if spec.Name.Name == "_" || spec.Name.Name == "." {
+			return true
+		}
+
+		names = map[string]bool{spec.Name.Name: true}
+	}
+
+	used := false
+	ast.Inspect(tree, func(node ast.Node) bool {
+		if sel, ok := node.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok && x.Obj == nil && names[x.Name] {
+				used = true
+			}
+		}
+
+		return !used
+	})
+
+	return used
+}
+
+// assumedImportName returns the package name of an import path as goimports assumes it: the last
+// element, or the one before a major version suffix like v2, without a go- prefix and cut at the
+// first character which can't be in an identifier, so gopkg.in/yaml.v2 and github.com/x/bar-go are
+// imported as yaml and bar.
+func assumedImportName(path string) string {
+	base := path[strings.LastIndex(path, "/")+1:]
+	if dir := strings.LastIndex(path, "/"); dir > 0 && strings.HasPrefix(base, "v") {
+		if _, err := strconv.Atoi(base[1:]); err == nil {
+			parent := path[:dir]
+			base = parent[strings.LastIndex(parent, "/")+1:]
+		}
+	}
+
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}); i >= 0 {
+		base = base[:i]
+	}
+
+	return base
+}
+
+// removeComments removes comment groups from the comments of a file.
+func removeComments(tree *ast.File, groups ...*ast.CommentGroup) {
+	comments := tree.Comments[:0]
+	for _, c := range tree.Comments {
+		keep := true
+		for _, g := range groups {
+			if c == g {
+				keep = false
+			}
+		}
+
+		if keep {
+			comments = append(comments, c)
+		}
+	}
+
+	tree.Comments = comments
+}
+
+// checkImportOptions returns an error if the import edits of a request are missing or have an empty
+// path.
+func checkImportOptions(opts *msg.ImportOptions) error {
+	if opts == nil {
+		return fmt.Errorf("no import edits in the request")
+	}
+
+	for _, r := range opts.Rewrite {
+		if r.Old == "" || r.New == "" {
+			return fmt.Errorf("empty path in the rewrite of %q to %q", r.Old, r.New)
+		}
+	}
+
+	for _, spec := range opts.Add {
+		if spec.Path == "" {
+			return fmt.Errorf("empty path in the import to add")
+		}
+	}
+
+	return nil
+}
+
+// groupImports sorts the imports of every parenthesized import declaration of a formatted source by
+// path, with the standard library imports, as isStdPath tells them, in a group before the other
+// ones. Declarations with comments which aren't attached to an import, or importing "C",
+// are left as they are, since there is no telling where the comments belong.
+func groupImports(source string) (string, error) {
+	fset := token.NewFileSet()
+	tree, err := parser.ParseFile(fset, "source.go", source, parseMode)
+	if err != nil {
+		return "", err
+	}
+
+	file := fset.File(tree.FileStart)
+	var out strings.Builder
+	last := 0
+	for _, decl := range tree.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT || !gen.Lparen.IsValid() {
+			continue
+		}
+
+		specs, ok := groupSpecs(file, tree, source, gen)
+		if !ok {
+			continue
+		}
+
+		out.WriteString(source[last : file.Offset(gen.Lparen)+1])
+		out.WriteString(specs)
+		last = file.Offset(gen.Rparen)
+	}
+
+	out.WriteString(source[last:])
+	grouped, err := format.Source([]byte(out.String()))
+	return string(grouped), err
+}
+
+// groupSpecs returns the content of a parenthesized import declaration with its imports sorted and
+// grouped. The doc and line comments of every import move along with it. It returns false if the
+// declaration can't be grouped.
+func groupSpecs(file *token.File, tree *ast.File, source string, decl *ast.GenDecl) (string, bool) {
+	attached := make(map[*ast.CommentGroup]bool)
+	type groupedSpec struct {
+		path, name, text string
+	}
+
+	var std, others []groupedSpec
+	for _, spec := range decl.Specs {
+		s := spec.(*ast.ImportSpec)
+		path, err := strconv.Unquote(s.Path.Value)
+		if err != nil || path == "C" {
+			return "", false
+		}
+
+		start, end := s.Pos(), s.End()
+		if s.Doc != nil {
+			attached[s.Doc] = true
+			start = s.Doc.Pos()
+		}
+
+		if s.Comment != nil {
+			attached[s.Comment] = true
+			end = s.Comment.End()
+		}
+
+		g := groupedSpec{path: path, text: source[file.Offset(start):file.Offset(end)]}
+		if s.Name != nil {
+			g.name = s.Name.Name
+		}
+
+		if isStdPath(path) {
+			std = append(std, g)
+		} else {
+			others = append(others, g)
+		}
+	}
+
+	for _, c := range tree.Comments {
+		if c.Pos() > decl.Lparen && c.End() < decl.Rparen && !attached[c] {
+			return "", false
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString("\n")
+	for i, group := range [][]groupedSpec{std, others} {
+		if len(group) == 0 {
+			continue
+		}
+
+		if i > 0 && len(std) > 0 {
+			out.WriteString("\n")
+		}
+
+		sort.SliceStable(group, func(i, j int) bool {
+			if group[i].path != group[j].path {
+				return group[i].path < group[j].path
+			}
+
+			return group[i].name < group[j].name
+		})
+
+		for _, g := range group {
+			out.WriteString("\t" + g.text + "\n")
+		}
+	}
+
+	return out.String(), true
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetImports(t *testing.T) {
+	content := `package foo
+
+import (
+	"github.com/pkg/errors"
+	"os"
+	"strings" // unused
+	yaml "gopkg.in/yaml.v2" // yaml parser
+	"fmt"
+)
+
+func F() error {
+	fmt.Println(os.Args, yaml.Marshal)
+	return errors.New("f")
+}
+`
+	res := getResponse(&msg.Request{
+		Action:  msg.Imports,
+		Content: content,
+		Imports: &msg.ImportOptions{
+			Rewrite:      []*msg.ImportRewrite{{Old: "github.com/pkg/errors", New: "github.com/src-d/errors"}},
+			DeleteUnused: true,
+			Add:          []*msg.ImportSpec{{Name: "ctx", Path: "context"}, {Path: "fmt"}},
+			Group:        true,
+		},
+	})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Equal(t, `package foo
+
+import (
+	ctx "context"
+	"fmt"
+	"os"
+
+	"github.com/src-d/errors"
+	yaml "gopkg.in/yaml.v2" // yaml parser
+)
+
+func F() error {
+	fmt.Println(os.Args, yaml.Marshal)
+	return errors.New("f")
+}
+`, res.Imports.Source)
+	require.Equal(t, []*msg.ImportChange{
+		{Change: "rewritten", Path: "github.com/src-d/errors", Old: "github.com/pkg/errors"},
+		{Change: "deleted", Path: "strings"},
+		{Change: "added", Name: "ctx", Path: "context"},
+		{Change: "grouped"},
+	}, res.Imports.Changes)
+}
+
+func TestGetImportsUnchanged(t *testing.T) {
+	content := "package foo\n\nimport (\n\t\"os\"\n\n\t// unattached\n\n\t\"fmt\"\n)\n\nvar _ = fmt.Sprint(os.Args)\n"
+	res := getResponse(&msg.Request{
+		Action:  msg.Imports,
+		Content: content,
+		Imports: &msg.ImportOptions{DeleteUnused: true, Group: true},
+	})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Equal(t, content, res.Imports.Source)
+	require.Empty(t, res.Imports.Changes)
+}
+
+func TestGetImportsErrors(t *testing.T) {
+	res := getResponse(&msg.Request{Action: msg.Imports, Content: "package foo\n"})
+	require.Equal(t, msg.Fatal, res.Status)
+	require.Equal(t, []string{"no import edits in the request"}, res.Errors)
+
+	res = getResponse(&msg.Request{
+		Action:  msg.Imports,
+		Content: "package foo\n",
+		Imports: &msg.ImportOptions{Add: []*msg.ImportSpec{{Name: "x"}}},
+	})
+	require.Equal(t, msg.Fatal, res.Status)
+
+	res = getResponse(&msg.Request{
+		Action:  msg.Imports,
+		Content: "package foo\n\nfunc {\n",
+		Imports: &msg.ImportOptions{Group: true},
+	})
+	require.Equal(t, msg.Error, res.Status)
+	require.Nil(t, res.Imports)
+}
+
+func TestGetImportsAssumedNames(t *testing.T) {
+	content := `package foo
+
+import (
+	"github.com/x/bar-go"
+	"github.com/x/go-bar"
+	"github.com/x/go-baz"
+	"github.com/x/mod/v2"
+	"gopkg.in/yaml.v2"
+	"gopkg.in/unused.v1"
+)
+
+var _ = []interface{}{yaml.Marshal, bar.X, mod.Y}
+`
+	res := getResponse(&msg.Request{
+		Action:  msg.Imports,
+		Content: content,
+		Imports: &msg.ImportOptions{DeleteUnused: true},
+	})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Equal(t, []*msg.ImportChange{
+		{Change: "deleted", Path: "github.com/x/go-baz"},
+		{Change: "deleted", Path: "gopkg.in/unused.v1"},
+	}, res.Imports.Changes)
+	require.Contains(t, res.Imports.Source, "\t\"gopkg.in/yaml.v2\"\n")
+	require.Contains(t, res.Imports.Source, "\t\"github.com/x/go-bar\"\n")
+}
+
+func TestAssumedImportName(t *testing.T) {
+	for path, name := range map[string]string{
+		"fmt":                  "fmt",
+		"gopkg.in/yaml.v2":     "yaml",
+		"github.com/x/go-bar":  "bar",
+		"github.com/x/bar-go":  "bar",
+		"github.com/x/mod/v2":  "mod",
+		"github.com/x/v2":      "x",
+		"github.com/x/vendors": "vendors",
+	} {
+		require.Equal(t, name, assumedImportName(path), path)
+	}
+}
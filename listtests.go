@@ -0,0 +1,211 @@
+package main
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+)
+
+// testPrefixes are the function name prefixes go test looks for, with their kind and the type of
+// their only parameter.
+var testPrefixes = []struct {
+	prefix string
+	kind   string
+	param  string
+}{
+	{"Test", "test", "T"},
+	{"Benchmark", "benchmark", "B"},
+	{"Fuzz", "fuzz", "F"},
+	{"Example", "example", ""},
+}
+
+// getListTests replies a msg.ListTests request with the test, benchmark, fuzz and example functions
+// of the content, in the same way go test finds them.
+func getListTests(m *msg.Request) *msg.Response {
+	res := newResponse()
+	fset := token.NewFileSet()
+	tree, ok := parseFile(res, fset, m.Content)
+	if !ok {
+		return res
+	}
+
+	examples := make(map[string]*doc.Example)
+	for _, ex := range doc.Examples(tree) {
+		examples["Example"+ex.Name] = ex
+	}
+
+	testing := importName(tree, "testing")
+	res.Tests = []*msg.TestFunc{}
+	for _, decl := range tree.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil {
+			continue
+		}
+
+		for _, p := range testPrefixes {
+			if !isTestName(fn.Name.Name, p.prefix) {
+				continue
+			}
+
+			if !isTestSignature(fn.Type, testing, p.param) {
+				break
+			}
+
+			test := &msg.TestFunc{
+				Name:  fn.Name.Name,
+				Kind:  p.kind,
+				Start: getPosition(fset, fn.Pos()),
+				End:   getPosition(fset, fn.End()),
+			}
+
+			if ex, ok := examples[fn.Name.Name]; ok && (ex.Output != "" || ex.EmptyOutput) {
+				output := ex.Output
+				test.Output = &output
+				test.Unordered = ex.Unordered
+			}
+
+			if p.param != "" && fn.Body != nil {
+				test.Subtests = getSubtests(fset, fn.Name.Name, fn.Body)
+			}
+
+			res.Tests = append(res.Tests, test)
+			break
+		}
+	}
+
+	return res
+}
+
+// isTestName reports whether name is prefix followed by nothing or by a name which doesn't start
+// with a lower case letter, as go test requires.
+func isTestName(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+
+	if len(name) == len(prefix) {
+		return true
+	}
+
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	return !unicode.IsLower(r)
+}
+
+// isTestSignature reports whether a function type takes only a *testing.<param> and returns nothing.
+// Examples take no parameters.
+func isTestSignature(ftype *ast.FuncType, testing, param string) bool {
+	if ftype.Results != nil && len(ftype.Results.List) > 0 {
+		return false
+	}
+
+	if param == "" {
+		return ftype.Params == nil || len(ftype.Params.List) == 0
+	}
+
+	if ftype.Params == nil || len(ftype.Params.List) != 1 || len(ftype.Params.List[0].Names) > 1 {
+		return false
+	}
+
+	star, ok := ftype.Params.List[0].Type.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+
+	switch x := star.X.(type) {
+	case *ast.SelectorExpr:
+		pkg, ok := x.X.(*ast.Ident)
+		return ok && pkg.Name == testing && x.Sel.Name == param
+	case *ast.Ident:
+		return testing == "." && x.Name == param
+	}
+
+	return false
+}
+
+// importName returns the name a file uses to refer an imported package: the import alias or the
+// last element of the path. It returns an empty string if the package isn't imported.
+func importName(tree *ast.File, path string) string {
+	for _, spec := range tree.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || p != path {
+			continue
+		}
+
+		if spec.Name != nil {
+			return spec.Name.Name
+		}
+
+		return path[strings.LastIndex(path, "/")+1:]
+	}
+
+	return ""
+}
+
+// getSubtests finds the Run calls with a literal name and a function literal in a body, and the
+// subtests nested in them.
+func getSubtests(fset *token.FileSet, parent string, body ast.Node) []*msg.Subtest {
+	var subtests []*msg.Subtest
+	ast.Inspect(body, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			return true
+		}
+
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Run" {
+			return true
+		}
+
+		if _, ok := sel.X.(*ast.Ident); !ok {
+			return true
+		}
+
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		fn, isFunc := call.Args[1].(*ast.FuncLit)
+		if !ok || lit.Kind != token.STRING || !isFunc {
+			return true
+		}
+
+		name, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			return true
+		}
+
+		fullName := parent + "/" + rewriteTestName(name)
+		subtests = append(subtests, &msg.Subtest{
+			Name:     name,
+			FullName: fullName,
+			Start:    getPosition(fset, call.Pos()),
+			Subtests: getSubtests(fset, fullName, fn.Body),
+		})
+
+		return false
+	})
+
+	return subtests
+}
+
+// rewriteTestName rewrites a subtest name as the testing package does: spaces are replaced by
+// underscores and non-printable characters are escaped.
+func rewriteTestName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case unicode.IsSpace(r):
+			b.WriteRune('_')
+		case !strconv.IsPrint(r):
+			s := strconv.QuoteRune(r)
+			b.WriteString(s[1 : len(s)-1])
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
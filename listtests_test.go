@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetListTests(t *testing.T) {
+	source := `package foo_test
+
+import (
+	"fmt"
+	check "testing"
+)
+
+func TestMain(m *check.M) {}
+
+func TestFoo(t *check.T) {
+	t.Run("first case", func(t *check.T) {
+		t.Run("nested", func(t *check.T) {})
+	})
+
+	for _, name := range []string{"a", "b"} {
+		t.Run(name, func(t *check.T) {})
+	}
+}
+
+func Testlower(t *check.T) {}
+
+func BenchmarkFoo(b *check.B) {
+	b.Run("small", func(b *check.B) {})
+}
+
+func FuzzFoo(f *check.F) {}
+
+func ExampleFoo() {
+	fmt.Println("foo")
+	// Output: foo
+}
+
+func ExampleBar_unordered() {
+	fmt.Println("a")
+	fmt.Println("b")
+	// Unordered output:
+	// b
+	// a
+}
+
+func ExampleNoOutput() {}
+`
+	res := getResponse(&msg.Request{Action: msg.ListTests, Content: source})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+
+	var names, kinds []string
+	for _, test := range res.Tests {
+		names = append(names, test.Name)
+		kinds = append(kinds, test.Kind)
+	}
+
+	require.Equal(t, []string{"TestFoo", "BenchmarkFoo", "FuzzFoo", "ExampleFoo", "ExampleBar_unordered", "ExampleNoOutput"}, names)
+	require.Equal(t, []string{"test", "benchmark", "fuzz", "example", "example", "example"}, kinds)
+
+	foo := res.Tests[0]
+	require.Equal(t, 10, foo.Start.Line)
+	require.Equal(t, 18, foo.End.Line)
+	require.Equal(t, []*msg.Subtest{
+		{
+			Name:     "first case",
+			FullName: "TestFoo/first_case",
+			Start:    msg.Position{Offset: 112, Line: 11, Column: 2},
+			Subtests: []*msg.Subtest{
+				{Name: "nested", FullName: "TestFoo/first_case/nested", Start: msg.Position{Offset: 153, Line: 12, Column: 3}},
+			},
+		},
+	}, foo.Subtests)
+
+	require.Len(t, res.Tests[1].Subtests, 1)
+	require.Equal(t, "BenchmarkFoo/small", res.Tests[1].Subtests[0].FullName)
+
+	require.Equal(t, "foo\n", *res.Tests[3].Output)
+	require.False(t, res.Tests[3].Unordered)
+	require.Equal(t, "b\na\n", *res.Tests[4].Output)
+	require.True(t, res.Tests[4].Unordered)
+	require.Nil(t, res.Tests[5].Output)
+}
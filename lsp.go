@@ -0,0 +1,444 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+)
+
+// JSON-RPC error codes replied by the LSP server.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+// semanticTokenTypes is the legend of the semantic tokens: the LSP token type of every kind of
+// msg.HighlightSpan, in the order of their indexes.
+var semanticTokenTypes = []struct{ kind, tokenType string }{
+	{"keyword", "keyword"},
+	{"type", "type"},
+	{"function", "function"},
+	{"method", "method"},
+	{"parameter", "parameter"},
+	{"field", "property"},
+	{"label", "label"},
+	{"package", "namespace"},
+	{"string", "string"},
+	{"number", "number"},
+	{"comment", "comment"},
+}
+
+// symbolKinds are the LSP SymbolKind of the kinds of msg.Symbol.
+var symbolKinds = map[string]int{
+	"method": 6,
+	"type":   5,
+	"func":   12,
+	"var":    13,
+	"const":  14,
+}
+
+// rpcMessage is a JSON-RPC request or notification read by the LSP server. Notifications have no ID.
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcResult is a successful JSON-RPC response. A nil result is written as null, as required.
+type rpcResult struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result"`
+}
+
+// rpcErrorResponse is a failed JSON-RPC response.
+type rpcErrorResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   *rpcError       `json:"error"`
+}
+
+// rpcError is the error of a failed JSON-RPC response.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// rpcNotification is a JSON-RPC notification written by the LSP server.
+type rpcNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// textDocumentParams are the params of the LSP requests about a single document. Positions are
+// only set in selectionRange requests.
+type textDocumentParams struct {
+	TextDocument struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	} `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+	Positions []msg.LSPPosition `json:"positions"`
+}
+
+// lspServer is a Language Server Protocol server over a stream, backed by the actions of the
+// driver. Documents are synchronized as a whole and kept by their URI.
+type lspServer struct {
+	in       *textproto.Reader
+	out      io.Writer
+	docs     map[string]string
+	shutdown bool
+}
+
+// startLSP launchs a loop to read LSP messages and write their responses and notifications, until
+// an exit notification or the end of the input. Exiting without a shutdown request is an error.
+func startLSP(in io.Reader, out io.Writer) error {
+	s := &lspServer{
+		in:   textproto.NewReader(bufio.NewReader(in)),
+		out:  out,
+		docs: make(map[string]string),
+	}
+
+	for {
+		body, err := s.read()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		m := &rpcMessage{}
+		if err := json.Unmarshal(body, m); err != nil {
+			err = s.write(&rpcErrorResponse{
+				JSONRPC: "2.0",
+				ID:      json.RawMessage("null"),
+				Error:   &rpcError{Code: rpcParseError, Message: err.Error()},
+			})
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if m.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit without shutdown")
+			}
+
+			return nil
+		}
+
+		if err := s.handle(m); err != nil {
+			return err
+		}
+	}
+}
+
+// read reads the content of the next message, framed by its Content-Length header.
+func (s *lspServer) read() ([]byte, error) {
+	header, err := s.in.ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF && len(header) == 0 {
+			return nil, io.EOF
+		}
+
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header: %v", err)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.in.R, body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// write writes a message framed by its Content-Length header.
+func (s *lspServer) write(v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+
+	_, err = s.out.Write(body)
+	return err
+}
+
+// handle replies a request, or applies a notification, with the function of its method. Unknown
+// notifications are ignored.
+func (s *lspServer) handle(m *rpcMessage) error {
+	var params textDocumentParams
+	if len(m.Params) > 0 {
+		if err := json.Unmarshal(m.Params, &params); err != nil {
+			return s.reply(m, nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()})
+		}
+	}
+
+	uri := params.TextDocument.URI
+	switch m.Method {
+	case "":
+		return s.reply(m, nil, &rpcError{Code: rpcInvalidRequest, Message: "no method in the request"})
+	case "initialize":
+		return s.reply(m, s.initialize(), nil)
+	case "shutdown":
+		s.shutdown = true
+		return s.reply(m, nil, nil)
+	case "textDocument/didOpen":
+		s.docs[uri] = params.TextDocument.Text
+		return s.publishDiagnostics(uri)
+	case "textDocument/didChange":
+		if len(params.ContentChanges) > 0 {
+			s.docs[uri] = params.ContentChanges[len(params.ContentChanges)-1].Text
+		}
+
+		return s.publishDiagnostics(uri)
+	case "textDocument/didClose":
+		delete(s.docs, uri)
+		return s.notify("textDocument/publishDiagnostics", map[string]interface{}{
+			"uri":         uri,
+			"diagnostics": []*msg.Diagnostic{},
+		})
+	case "textDocument/documentSymbol", "textDocument/foldingRange", "textDocument/selectionRange",
+		"textDocument/semanticTokens/full":
+		content, ok := s.docs[uri]
+		if !ok {
+			return s.reply(m, nil, &rpcError{Code: rpcInvalidParams, Message: "unknown document: " + uri})
+		}
+
+		result, rpcErr := s.documentRequest(m.Method, content, &params)
+		return s.reply(m, result, rpcErr)
+	default:
+		if m.ID == nil {
+			return nil
+		}
+
+		return s.reply(m, nil, &rpcError{Code: rpcMethodNotFound, Message: "unknown method: " + m.Method})
+	}
+}
+
+// documentRequest replies the requests about the content of a document with the response of the
+// driver action which computes it.
+func (s *lspServer) documentRequest(method, content string, params *textDocumentParams) (interface{}, *rpcError) {
+	file := lspFile(content)
+	switch method {
+	case "textDocument/documentSymbol":
+		res := getResponse(&msg.Request{Action: msg.Outline, Content: content})
+		symbols := []*msg.DocumentSymbol{}
+		if res.Outline == nil {
+			return symbols, nil
+		}
+
+		for _, symbol := range res.Outline.Symbols {
+			r := msg.LSPRange{
+				Start: lspPosition(file, content, symbol.Start.Offset),
+				End:   lspPosition(file, content, symbol.End.Offset),
+			}
+
+			symbols = append(symbols, &msg.DocumentSymbol{
+				Name:           symbol.Name,
+				Detail:         symbol.Signature,
+				Kind:           symbolKinds[symbol.Kind],
+				Range:          r,
+				SelectionRange: r,
+			})
+		}
+
+		return symbols, nil
+	case "textDocument/foldingRange":
+		res := getResponse(&msg.Request{Action: msg.FoldingRanges, Content: content})
+		return res.FoldingRanges, nil
+	case "textDocument/selectionRange":
+		req := &msg.Request{Action: msg.SelectionRanges, Content: content}
+		for _, pos := range params.Positions {
+			req.Positions = append(req.Positions, &msg.Position{Offset: lspOffset(file, content, pos)})
+		}
+
+		res := getResponse(req)
+		if res.Status == msg.Fatal {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: strings.Join(res.Errors, "\n")}
+		}
+
+		return res.SelectionRanges, nil
+	default:
+		res := getResponse(&msg.Request{Action: msg.Highlight, Content: content})
+		return map[string]interface{}{"data": semanticTokens(file, content, res.Highlight)}, nil
+	}
+}
+
+// initialize replies an initialize request with the capabilities of the server.
+func (s *lspServer) initialize() interface{} {
+	tokenTypes := make([]string, len(semanticTokenTypes))
+	for i, t := range semanticTokenTypes {
+		tokenTypes[i] = t.tokenType
+	}
+
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			// the whole document is sent on every change
+			"textDocumentSync":       1,
+			"documentSymbolProvider": true,
+			"foldingRangeProvider":   true,
+			"selectionRangeProvider": true,
+			"semanticTokensProvider": map[string]interface{}{
+				"legend": map[string]interface{}{
+					"tokenTypes":     tokenTypes,
+					"tokenModifiers": []string{},
+				},
+				"full": true,
+			},
+		},
+		"serverInfo": map[string]interface{}{
+			"name":    "babelfish-go-driver",
+			"version": driverVersion,
+		},
+	}
+}
+
+// publishDiagnostics notifies the syntax errors of a document.
+func (s *lspServer) publishDiagnostics(uri string) error {
+	content := s.docs[uri]
+	return s.notify("textDocument/publishDiagnostics", map[string]interface{}{
+		"uri":         uri,
+		"diagnostics": getDiagnostics(content),
+	})
+}
+
+// reply writes the response of a request, or nothing for a notification.
+func (s *lspServer) reply(m *rpcMessage, result interface{}, rpcErr *rpcError) error {
+	if m.ID == nil {
+		return nil
+	}
+
+	if rpcErr != nil {
+		return s.write(&rpcErrorResponse{JSONRPC: "2.0", ID: m.ID, Error: rpcErr})
+	}
+
+	return s.write(&rpcResult{JSONRPC: "2.0", ID: m.ID, Result: result})
+}
+
+// notify writes a notification.
+func (s *lspServer) notify(method string, params interface{}) error {
+	return s.write(&rpcNotification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// getDiagnostics returns the syntax errors of a content, as parse reports them.
+func getDiagnostics(content string) []*msg.Diagnostic {
+	diagnostics := []*msg.Diagnostic{}
+	_, err := parser.ParseFile(token.NewFileSet(), "source.go", content, parseMode)
+	errList, ok := err.(scanner.ErrorList)
+	if !ok {
+		return diagnostics
+	}
+
+	file := lspFile(content)
+	for _, e := range errList {
+		pos := lspPosition(file, content, e.Pos.Offset)
+		diagnostics = append(diagnostics, &msg.Diagnostic{
+			Range:    msg.LSPRange{Start: pos, End: pos},
+			Severity: 1,
+			Source:   "syntax",
+			Message:  e.Msg,
+		})
+	}
+
+	return diagnostics
+}
+
+// semanticTokens encodes highlighting spans as LSP semantic tokens: five integers per token with
+// the line and the start relative to the previous token, the length, the type and no modifiers.
+// Spans over several lines, like block comments, are split into a token per line.
+func semanticTokens(file *token.File, content string, spans []*msg.HighlightSpan) []int {
+	types := make(map[string]int, len(semanticTokenTypes))
+	for i, t := range semanticTokenTypes {
+		types[t.kind] = i
+	}
+
+	data := []int{}
+	var last msg.LSPPosition
+	for _, span := range spans {
+		offset := span.Start.Offset
+		for _, line := range strings.SplitAfter(content[offset:span.End.Offset], "\n") {
+			text := strings.TrimRight(line, "\r\n")
+			start := lspPosition(file, content, offset)
+			offset += len(line)
+			if text == "" {
+				continue
+			}
+
+			character := start.Character
+			if start.Line == last.Line {
+				character -= last.Character
+			}
+
+			data = append(data, start.Line-last.Line, character, utf16Len(text), types[span.Kind], 0)
+			last = start
+		}
+	}
+
+	return data
+}
+
+// lspFile returns a token.File with the lines of a content, to resolve its LSP positions.
+func lspFile(content string) *token.File {
+	file := token.NewFileSet().AddFile("source.go", -1, len(content))
+	file.SetLinesForContent([]byte(content))
+	return file
+}
+
+// lspOffset returns the offset of an LSP position in the content of a file. Positions past the end
+// of a line are resolved to its end, and positions past the end of the content to the content end.
+func lspOffset(file *token.File, content string, pos msg.LSPPosition) int {
+	if pos.Line < 0 {
+		return 0
+	}
+
+	if pos.Line >= file.LineCount() {
+		return len(content)
+	}
+
+	offset := file.Offset(file.LineStart(pos.Line + 1))
+	for character := 0; offset < len(content) && character < pos.Character; {
+		r, size := utf8.DecodeRuneInString(content[offset:])
+		if r == '\n' {
+			break
+		}
+
+		character++
+		if r >= 0x10000 {
+			character++
+		}
+
+		offset += size
+	}
+
+	return offset
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// lspInput frames LSP messages as a client writes them.
+func lspInput(t *testing.T, messages ...string) *bytes.Buffer {
+	input := &bytes.Buffer{}
+	for _, m := range messages {
+		require.True(t, json.Valid([]byte(m)), m)
+		fmt.Fprintf(input, "Content-Length: %d\r\n\r\n%s", len(m), m)
+	}
+
+	return input
+}
+
+// lspOutput reads the messages written by the LSP server.
+func lspOutput(t *testing.T, output *bytes.Buffer) []map[string]interface{} {
+	var messages []map[string]interface{}
+	r := textproto.NewReader(bufio.NewReader(output))
+	for {
+		header, err := r.ReadMIMEHeader()
+		if len(header) == 0 {
+			return messages
+		}
+
+		require.NoError(t, err)
+		length, err := strconv.Atoi(header.Get("Content-Length"))
+		require.NoError(t, err)
+
+		body := make([]byte, length)
+		_, err = io.ReadFull(r.R, body)
+		require.NoError(t, err)
+
+		m := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal(body, &m))
+		messages = append(messages, m)
+	}
+}
+
+func TestStartLSP(t *testing.T) {
+	input := lspInput(t,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///a.go","text":"package foo\n\nfunc F( {\n"}}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///a.go"},"contentChanges":[{"text":"package foo\n\n// F\nfunc F() {\n\tx := 1\n}\n"}]}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/documentSymbol","params":{"textDocument":{"uri":"file:///a.go"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"textDocument/foldingRange","params":{"textDocument":{"uri":"file:///a.go"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"textDocument/selectionRange","params":{"textDocument":{"uri":"file:///a.go"},"positions":[{"line":4,"character":1}]}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"textDocument/semanticTokens/full","params":{"textDocument":{"uri":"file:///a.go"}}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///a.go"}}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"textDocument/foldingRange","params":{"textDocument":{"uri":"file:///b.go"}}}`,
+		`{"jsonrpc":"2.0","id":8,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+
+	output := &bytes.Buffer{}
+	require.NoError(t, startLSP(input, output))
+	messages := lspOutput(t, output)
+	require.Len(t, messages, 10)
+
+	capabilities := messages[0]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+	require.Equal(t, true, capabilities["foldingRangeProvider"])
+
+	require.Equal(t, "textDocument/publishDiagnostics", messages[1]["method"])
+	diagnostics := messages[1]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	require.NotEmpty(t, diagnostics)
+	require.Equal(t, map[string]interface{}{"line": 2.0, "character": 8.0},
+		diagnostics[0].(map[string]interface{})["range"].(map[string]interface{})["start"])
+
+	require.Equal(t, "textDocument/publishDiagnostics", messages[2]["method"])
+	require.Empty(t, messages[2]["params"].(map[string]interface{})["diagnostics"])
+
+	jsonEqual := func(want string, got interface{}) {
+		data, err := json.Marshal(got)
+		require.NoError(t, err)
+		require.JSONEq(t, want, string(data))
+	}
+
+	jsonEqual(`[{"name":"F","detail":"func F()","kind":12,
+		"range":{"start":{"line":3,"character":0},"end":{"line":5,"character":1}},
+		"selectionRange":{"start":{"line":3,"character":0},"end":{"line":5,"character":1}}}]`, messages[3]["result"])
+	jsonEqual(`[{"startLine":3,"startCharacter":10,"endLine":5,"endCharacter":0}]`, messages[4]["result"])
+	jsonEqual(`[{"range":{"start":{"line":4,"character":1},"end":{"line":4,"character":2}},
+		"parent":{"range":{"start":{"line":4,"character":1},"end":{"line":4,"character":7}},
+		"parent":{"range":{"start":{"line":3,"character":9},"end":{"line":5,"character":1}},
+		"parent":{"range":{"start":{"line":3,"character":0},"end":{"line":5,"character":1}},
+		"parent":{"range":{"start":{"line":0,"character":0},"end":{"line":6,"character":0}}}}}}}]`, messages[5]["result"])
+	// package foo, the comment, func F and the number
+	jsonEqual(`{"data":[0,0,7,0,0, 0,8,3,7,0, 2,0,4,10,0, 1,0,4,0,0, 0,5,1,2,0, 1,6,1,9,0]}`, messages[6]["result"])
+
+	require.Equal(t, -32601.0, messages[7]["error"].(map[string]interface{})["code"])
+	require.Equal(t, -32602.0, messages[8]["error"].(map[string]interface{})["code"])
+	require.Contains(t, messages[9], "result")
+	require.Nil(t, messages[9]["result"])
+}
+
+func TestStartLSPExitWithoutShutdown(t *testing.T) {
+	input := lspInput(t, `{"jsonrpc":"2.0","method":"exit"}`)
+	require.Error(t, startLSP(input, &bytes.Buffer{}))
+}
+
+func TestStartLSPInvalidMessage(t *testing.T) {
+	input := &bytes.Buffer{}
+	fmt.Fprintf(input, "Content-Length: 3\r\n\r\n{]}")
+
+	output := &bytes.Buffer{}
+	require.NoError(t, startLSP(input, output))
+	messages := lspOutput(t, output)
+	require.Len(t, messages, 1)
+	require.Equal(t, -32700.0, messages[0]["error"].(map[string]interface{})["code"])
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"go/ast"
 	"go/parser"
@@ -10,6 +11,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"reflect"
 	"runtime"
 
 	"github.com/src-d/babelfish-go-driver/msg"
@@ -17,6 +19,8 @@ import (
 
 const (
 	lang = "Go"
+	// parseMode is the mode of the parser for the content of requests.
+	parseMode = parser.ParseComments | parser.AllErrors
 )
 
 var (
@@ -25,10 +29,18 @@ var (
 )
 
 func main() {
+	lsp := flag.Bool("lsp", false, "speak the Language Server Protocol instead of the driver protocol")
+	flag.Parse()
+
 	in := os.Stdin
 	out := os.Stdout
 
-	if err := start(in, out); err != nil {
+	serve := start
+	if *lsp {
+		serve = startLSP
+	}
+
+	if err := serve(in, out); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -41,6 +53,8 @@ func start(in io.Reader, out io.Writer) error {
 	var res *msg.Response
 
 	for {
+		// fields missing in the next request mustn't keep the values of the previous one
+		*req = msg.Request{}
 		if err := dec.Decode(req); err != nil {
 			if err == io.EOF {
 				break
@@ -72,26 +86,143 @@ func start(in io.Reader, out io.Writer) error {
 
 // getResponse always generates a msg.Response. The response will have the properly status (Ok, Error, Fatal).
 func getResponse(m *msg.Request) *msg.Response {
-	res := &msg.Response{
+	res := handle(m)
+	if res.Status != msg.Fatal && isGoContent(m) {
+		res.Generator, res.Generated = generator(m.Content)
+	}
+
+	return res
+}
+
+// isGoContent reports whether the content of a request is a Go source. Archives, go.mod, go.work and
+// go.sum files and templates aren't, and the files of a package carry their own generated marks.
+func isGoContent(m *msg.Request) bool {
+	if m.Archive {
+		return false
+	}
+
+	switch m.Action {
+	case msg.ParsePackage, msg.ParseGoMod, msg.ParseGoWork, msg.ParseGoSum, msg.ParseTemplate:
+		return false
+	}
+
+	return true
+}
+
+// handle replies a request with the function of its action.
+func handle(m *msg.Request) *msg.Response {
+	if m.Identifiers && (m.Archive || m.Action != msg.ParseAst && m.Action != "") {
+		res := newResponse()
+		res.Status = msg.Fatal
+		res.Errors = []string{fmt.Sprintf("action %q doesn't accept the identifiers option", m.Action)}
+		if m.Archive {
+			res.Errors = []string{"archives don't accept the identifiers option"}
+		}
+
+		return res
+	}
+
+	if m.Archive {
+		switch m.Action {
+		case msg.ParseAst, msg.ParsePackage, "":
+			return getParseArchive(m)
+		}
+
+		res := newResponse()
+		res.Status = msg.Fatal
+		res.Errors = []string{fmt.Sprintf("action %q doesn't accept archives", m.Action)}
+		return res
+	}
+
+	switch m.Action {
+	case msg.ParseAst, "":
+		return getParseAST(m)
+	case msg.Format:
+		return getFormat(m)
+	case msg.TypeCheck:
+		return getTypeCheck(m)
+	case msg.ParsePackage:
+		return getParsePackage(m)
+	case msg.Tokenize:
+		return getTokenize(m)
+	case msg.Outline:
+		return getOutline(m)
+	case msg.Metrics:
+		return getMetrics(m)
+	case msg.ListTests:
+		return getListTests(m)
+	case msg.Directives:
+		return getDirectives(m)
+	case msg.ParseGoMod:
+		return getParseGoMod(m)
+	case msg.ParseGoWork:
+		return getParseGoWork(m)
+	case msg.ParseGoSum:
+		return getParseGoSum(m)
+	case msg.ParseTemplate:
+		return getParseTemplate(m)
+	case msg.PathContexts:
+		return getPathContexts(m)
+	case msg.Diff:
+		return getASTDiff(m)
+	case msg.Changes:
+		return getChanges(m)
+	case msg.APIDiff:
+		return getAPIDiff(m)
+	case msg.Query:
+		return getQuery(m)
+	case msg.NodeAt:
+		return getNodeAt(m)
+	case msg.FoldingRanges:
+		return getFoldingRanges(m)
+	case msg.SelectionRanges:
+		return getSelectionRanges(m)
+	case msg.Highlight:
+		return getHighlight(m)
+	case msg.Rename:
+		return getRename(m)
+	case msg.Imports:
+		return getImports(m)
+	default:
+		res := newResponse()
+		res.Status = msg.Fatal
+		res.Errors = []string{fmt.Sprintf("unknown action: %q", m.Action)}
+		return res
+	}
+}
+
+// newResponse creates a msg.Response filled with the driver and language information.
+func newResponse() *msg.Response {
+	return &msg.Response{
 		Language:        lang,
 		LanguageVersion: langVersion,
 		Driver:          driverVersion,
 	}
+}
 
+// getParseAST replies a msg.ParseAst request with the AST of the content. Fragments parsed with a
+// snippet mode are replied in the response snippet instead.
+func getParseAST(m *msg.Request) *msg.Response {
+	switch m.Mode {
+	case msg.ModeFile, "":
+	case msg.ModeExpr, msg.ModeStmts, msg.ModeDecls:
+		return getSnippet(m)
+	default:
+		res := newResponse()
+		res.Status = msg.Fatal
+		res.Errors = []string{fmt.Sprintf("unknown mode: %q", m.Mode)}
+		return res
+	}
+
+	res := newResponse()
 	fset := token.NewFileSet()
-	tree, err := parser.ParseFile(fset, "source.go", m.Content, parser.ParseComments|parser.AllErrors)
-	if err != nil {
-		if tree == nil {
-			res.Status = msg.Fatal
-			res.Errors = []string{err.Error()}
-			return res
-		}
+	tree, ok := parseFile(res, fset, m.Content)
+	if !ok {
+		return res
+	}
 
-		res.Status = msg.Error
-		errList := err.(scanner.ErrorList)
-		res.Errors = getErrors(errList)
-	} else {
-		res.Status = msg.Ok
+	if m.Identifiers {
+		res.Identifiers = getIdentifiers(fset, tree)
 	}
 
 	ast.Inspect(tree, setObjNil)
@@ -100,6 +231,32 @@ func getResponse(m *msg.Request) *msg.Response {
 	return res
 }
 
+// parseFile parses the content and sets the status and errors of the response. If the returned bool
+// is false the response is fatal and the tree mustn't be used.
+func parseFile(res *msg.Response, fset *token.FileSet, content string) (*ast.File, bool) {
+	tree, status, errors := parse(fset, "source.go", content)
+	res.Status = status
+	res.Errors = errors
+
+	return tree, status != msg.Fatal
+}
+
+// parse parses a named source and returns the tree, the status and the errors. The tree is nil if
+// the status is msg.Fatal.
+func parse(fset *token.FileSet, name, content string) (*ast.File, string, []string) {
+	tree, err := parser.ParseFile(fset, name, content, parseMode)
+	if err != nil {
+		if tree == nil {
+			return nil, msg.Fatal, []string{err.Error()}
+		}
+
+		errList := err.(scanner.ErrorList)
+		return tree, msg.Error, getErrors(errList)
+	}
+
+	return tree, msg.Ok, nil
+}
+
 // getErrors build a []string with the err.Error() from a scanner.ErrorList.
 func getErrors(errList scanner.ErrorList) []string {
 	list := make([]string, 0, len(errList))
@@ -109,3 +266,28 @@ func getErrors(errList scanner.ErrorList) []string {
 
 	return list
 }
+
+// nodeType returns the type name of an AST node without the package, i.e. CallExpr.
+func nodeType(node ast.Node) string {
+	return reflect.TypeOf(node).Elem().Name()
+}
+
+// getPosition resolves a token.Pos to a msg.Position.
+func getPosition(fset *token.FileSet, pos token.Pos) msg.Position {
+	p := fset.Position(pos)
+	return msg.Position{
+		Offset: p.Offset,
+		Line:   p.Line,
+		Column: p.Column,
+	}
+}
+
+// getOffsetPosition resolves an offset of a file to a msg.Position.
+func getOffsetPosition(file *token.File, offset int) msg.Position {
+	p := file.Position(file.Pos(offset))
+	return msg.Position{
+		Offset: offset,
+		Line:   p.Line,
+		Column: p.Column,
+	}
+}
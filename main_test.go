@@ -82,7 +82,7 @@ func TestCmd(t *testing.T) {
 
 		// run command
 		dv := fmt.Sprintf("-X main.driverVersion=%v", driverTestVersion)
-		cmd := exec.Command("go", "run", "-ldflags", dv, "main.go", "conf_nodes.go")
+		cmd := exec.Command("go", "run", "-ldflags", dv, ".")
 		cmd.Stdin = input
 		cmd.Stdout = output
 		err = cmd.Run()
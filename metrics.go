@@ -0,0 +1,363 @@
+package main
+
+import (
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"math"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+)
+
+// getMetrics replies a msg.Metrics request with the code metrics of every function and method
+// declaration of the content, and the totals of the file.
+func getMetrics(m *msg.Request) *msg.Response {
+	res := newResponse()
+	fset := token.NewFileSet()
+	tree, ok := parseFile(res, fset, m.Content)
+	if !ok {
+		return res
+	}
+
+	file := fset.File(tree.FileStart)
+	tokens := scanTokens(file, []byte(m.Content))
+	metrics := &msg.CodeMetrics{
+		Total: &msg.FileMetrics{
+			HalsteadVolume: halsteadVolume(tokens),
+			SLOC:           countLines(tokens),
+			Lines:          file.LineCount(),
+		},
+	}
+
+	for _, decl := range tree.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+
+		fm := getFuncMetrics(fset, fn, tokensIn(tokens, file.Offset(fn.Pos()), file.Offset(fn.End())))
+		metrics.Functions = append(metrics.Functions, fm)
+
+		metrics.Total.Functions++
+		metrics.Total.Cyclomatic += fm.Cyclomatic
+		metrics.Total.Cognitive += fm.Cognitive
+		if fm.Nesting > metrics.Total.Nesting {
+			metrics.Total.Nesting = fm.Nesting
+		}
+	}
+
+	res.Metrics = metrics
+	return res
+}
+
+// getFuncMetrics computes the metrics of a function declaration from its AST and its tokens.
+func getFuncMetrics(fset *token.FileSet, fn *ast.FuncDecl, tokens []scannedToken) *msg.FuncMetrics {
+	fm := &msg.FuncMetrics{
+		Name:           fn.Name.Name,
+		Start:          getPosition(fset, fn.Pos()),
+		End:            getPosition(fset, fn.End()),
+		Cyclomatic:     cyclomatic(fn),
+		Params:         countFields(fn.Type.Params),
+		Results:        countFields(fn.Type.Results),
+		HalsteadVolume: halsteadVolume(tokens),
+		SLOC:           countLines(tokens),
+	}
+
+	if fn.Recv != nil && len(fn.Recv.List) > 0 {
+		fm.Recv = render(fset, fn.Recv.List[0].Type)
+	}
+
+	if fn.Body != nil {
+		v := newCognitiveVisitor(fn)
+		ast.Walk(v, fn.Body)
+		fm.Cognitive = v.complexity
+		fm.Nesting = v.maxNesting
+	}
+
+	return fm
+}
+
+// cyclomatic computes the cyclomatic complexity of a function: one plus the number of branches and
+// logical operators, including the ones of function literals.
+func cyclomatic(fn *ast.FuncDecl) int {
+	complexity := 1
+	ast.Inspect(fn, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			if n.List != nil {
+				complexity++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				complexity++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				complexity++
+			}
+		}
+
+		return true
+	})
+
+	return complexity
+}
+
+// countFields returns the number of parameters or results of a field list. Unnamed fields count
+// as one.
+func countFields(list *ast.FieldList) int {
+	if list == nil {
+		return 0
+	}
+
+	count := 0
+	for _, field := range list.List {
+		if len(field.Names) == 0 {
+			count++
+		} else {
+			count += len(field.Names)
+		}
+	}
+
+	return count
+}
+
+// cognitiveVisitor is an ast.Visitor which computes the cognitive complexity of a function:
+// control structures increment it by one plus their nesting level, else branches, labeled jumps
+// and sequences of mixed logical operators by one, and so does every recursive call.
+type cognitiveVisitor struct {
+	fn         *ast.FuncDecl
+	complexity int
+	nesting    int
+	maxNesting int
+	// logical holds the logical expressions already counted as part of a sequence.
+	logical map[*ast.BinaryExpr]bool
+}
+
+// newCognitiveVisitor creates a cognitiveVisitor for the body of a function declaration.
+func newCognitiveVisitor(fn *ast.FuncDecl) *cognitiveVisitor {
+	return &cognitiveVisitor{
+		fn:      fn,
+		logical: make(map[*ast.BinaryExpr]bool),
+	}
+}
+
+// Visit implements ast.Visitor.
+func (v *cognitiveVisitor) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.IfStmt:
+		v.visitIf(n, false)
+		return nil
+	case *ast.SwitchStmt:
+		v.structure()
+		v.walk(n.Init, n.Tag)
+		v.nested(n.Body)
+		return nil
+	case *ast.TypeSwitchStmt:
+		v.structure()
+		v.walk(n.Init, n.Assign)
+		v.nested(n.Body)
+		return nil
+	case *ast.SelectStmt:
+		v.structure()
+		v.nested(n.Body)
+		return nil
+	case *ast.ForStmt:
+		v.structure()
+		v.walk(n.Init, n.Cond, n.Post)
+		v.nested(n.Body)
+		return nil
+	case *ast.RangeStmt:
+		v.structure()
+		v.walk(n.Key, n.Value, n.X)
+		v.nested(n.Body)
+		return nil
+	case *ast.FuncLit:
+		v.nested(n.Body)
+		return nil
+	case *ast.BranchStmt:
+		if n.Label != nil {
+			v.complexity++
+		}
+	case *ast.BinaryExpr:
+		v.visitLogical(n)
+	case *ast.CallExpr:
+		if v.isRecursive(n) {
+			v.complexity++
+		}
+	}
+
+	return v
+}
+
+// visitIf walks an if statement and its else branches. Else branches don't add the nesting level.
+func (v *cognitiveVisitor) visitIf(n *ast.IfStmt, isElse bool) {
+	if isElse {
+		v.complexity++
+	} else {
+		v.structure()
+	}
+
+	v.walk(n.Init, n.Cond)
+	v.nested(n.Body)
+	switch e := n.Else.(type) {
+	case *ast.IfStmt:
+		v.visitIf(e, true)
+	case *ast.BlockStmt:
+		v.complexity++
+		v.nested(e)
+	}
+}
+
+// visitLogical increments the complexity by one for every sequence of the same logical operator.
+func (v *cognitiveVisitor) visitLogical(n *ast.BinaryExpr) {
+	if v.logical[n] || (n.Op != token.LAND && n.Op != token.LOR) {
+		return
+	}
+
+	var last token.Token
+	for _, op := range v.logicalOps(n) {
+		if op != last {
+			v.complexity++
+			last = op
+		}
+	}
+}
+
+// logicalOps returns in source order the logical operators of an expression and its operands.
+func (v *cognitiveVisitor) logicalOps(expr ast.Expr) []token.Token {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return v.logicalOps(e.X)
+	case *ast.BinaryExpr:
+		if e.Op != token.LAND && e.Op != token.LOR {
+			return nil
+		}
+
+		v.logical[e] = true
+		ops := append(v.logicalOps(e.X), e.Op)
+		return append(ops, v.logicalOps(e.Y)...)
+	}
+
+	return nil
+}
+
+// isRecursive reports whether a call invokes the visited function. Methods are only matched when
+// called on the receiver.
+func (v *cognitiveVisitor) isRecursive(call *ast.CallExpr) bool {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return v.fn.Recv == nil && fun.Name == v.fn.Name.Name
+	case *ast.SelectorExpr:
+		if v.fn.Recv == nil || len(v.fn.Recv.List) == 0 || len(v.fn.Recv.List[0].Names) == 0 {
+			return false
+		}
+
+		x, ok := fun.X.(*ast.Ident)
+		return ok && x.Name == v.fn.Recv.List[0].Names[0].Name && fun.Sel.Name == v.fn.Name.Name
+	}
+
+	return false
+}
+
+// structure increments the complexity of a control structure at the current nesting level.
+func (v *cognitiveVisitor) structure() {
+	v.complexity += 1 + v.nesting
+}
+
+// walk visits the non-nil nodes at the current nesting level.
+func (v *cognitiveVisitor) walk(nodes ...ast.Node) {
+	for _, node := range nodes {
+		if node != nil {
+			ast.Walk(v, node)
+		}
+	}
+}
+
+// nested visits a node one nesting level deeper.
+func (v *cognitiveVisitor) nested(node ast.Node) {
+	v.nesting++
+	if v.nesting > v.maxNesting {
+		v.maxNesting = v.nesting
+	}
+
+	v.walk(node)
+	v.nesting--
+}
+
+// scannedToken is a token of the content without comments nor inserted semicolons.
+type scannedToken struct {
+	tok    token.Token
+	lit    string
+	offset int
+	line   int
+}
+
+// scanTokens returns the tokens of a source. Scanner errors are ignored, since they have already
+// been reported by the parser.
+func scanTokens(file *token.File, src []byte) []scannedToken {
+	var tokens []scannedToken
+	var s scanner.Scanner
+	scanned := token.NewFileSet().AddFile(file.Name(), -1, len(src))
+	s.Init(scanned, src, nil, 0)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		if tok == token.SEMICOLON && lit != ";" {
+			continue
+		}
+
+		offset := scanned.Offset(pos)
+		tokens = append(tokens, scannedToken{tok: tok, lit: lit, offset: offset, line: file.Line(file.Pos(offset))})
+	}
+
+	return tokens
+}
+
+// tokensIn returns the tokens between the start and end offsets.
+func tokensIn(tokens []scannedToken, start, end int) []scannedToken {
+	var in []scannedToken
+	for _, t := range tokens {
+		if t.offset >= start && t.offset < end {
+			in = append(in, t)
+		}
+	}
+
+	return in
+}
+
+// halsteadVolume computes the Halstead volume, N * log2(n), of a list of tokens. Identifiers and
+// literals are operands and every other token is an operator.
+func halsteadVolume(tokens []scannedToken) float64 {
+	operators := make(map[token.Token]bool)
+	operands := make(map[string]bool)
+	for _, t := range tokens {
+		if t.tok.IsLiteral() {
+			operands[t.lit] = true
+		} else {
+			operators[t.tok] = true
+		}
+	}
+
+	vocabulary := len(operators) + len(operands)
+	if vocabulary == 0 {
+		return 0
+	}
+
+	return float64(len(tokens)) * math.Log2(float64(vocabulary))
+}
+
+// countLines returns the number of distinct lines with tokens.
+func countLines(tokens []scannedToken) int {
+	lines := make(map[int]bool)
+	for _, t := range tokens {
+		lines[t.line] = true
+	}
+
+	return len(lines)
+}
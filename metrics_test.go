@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMetrics(t *testing.T) {
+	source := `package foo
+
+// sum adds the positive numbers.
+func sum(list []int) (total int) {
+	for _, n := range list { // +1 cognitive, +1 cyclomatic
+		if n > 0 && n < 100 || n == -1 { // +2 if nested, +2 logical sequences, +3 cyclomatic
+			total += n
+		} else { // +1
+			continue
+		}
+	}
+
+	return total
+}
+
+func (t *T) fact(n int) int {
+	switch { // +1
+	case n <= 1: // +1 cyclomatic
+		return 1
+	default:
+		return n * t.fact(n-1) // +1 recursion
+	}
+}
+
+func empty() {}
+`
+	res := getResponse(&msg.Request{Action: msg.Metrics, Content: source})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Len(t, res.Metrics.Functions, 3)
+
+	sum := res.Metrics.Functions[0]
+	require.Equal(t, "sum", sum.Name)
+	require.Equal(t, 4, sum.Start.Line)
+	require.Equal(t, 14, sum.End.Line)
+	require.Equal(t, 5, sum.Cyclomatic)
+	require.Equal(t, 6, sum.Cognitive)
+	require.Equal(t, 2, sum.Nesting)
+	require.Equal(t, 1, sum.Params)
+	require.Equal(t, 1, sum.Results)
+	require.Equal(t, 10, sum.SLOC)
+	require.True(t, sum.HalsteadVolume > 0)
+
+	fact := res.Metrics.Functions[1]
+	require.Equal(t, "fact", fact.Name)
+	require.Equal(t, "*T", fact.Recv)
+	require.Equal(t, 2, fact.Cyclomatic)
+	require.Equal(t, 2, fact.Cognitive)
+	require.Equal(t, 1, fact.Nesting)
+
+	empty := res.Metrics.Functions[2]
+	require.Equal(t, &msg.FuncMetrics{
+		Name:           "empty",
+		Start:          msg.Position{Offset: 440, Line: 25, Column: 1},
+		End:            msg.Position{Offset: 455, Line: 25, Column: 16},
+		Cyclomatic:     1,
+		HalsteadVolume: 6 * math.Log2(6),
+		SLOC:           1,
+	}, empty)
+
+	total := res.Metrics.Total
+	require.Equal(t, 3, total.Functions)
+	require.Equal(t, 8, total.Cyclomatic)
+	require.Equal(t, 8, total.Cognitive)
+	require.Equal(t, 2, total.Nesting)
+	require.Equal(t, 20, total.SLOC)
+	require.Equal(t, 25, total.Lines)
+}
+
+func TestGetMetricsSyntaxErrors(t *testing.T) {
+	for _, content := range []string{"", "package", "package p\n/* unterminated", "package p\nfunc F() {\n\tif x {\n"} {
+		res := getResponse(&msg.Request{Action: msg.Metrics, Content: content})
+		require.Equal(t, msg.Error, res.Status, content)
+		require.NotNil(t, res.Metrics, content)
+	}
+}
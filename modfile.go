@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"golang.org/x/mod/modfile"
+)
+
+// getParseGoMod replies a msg.ParseGoMod request with the directives of the go.mod content. If the
+// file isn't valid the errors are replied with msg.Error status, together with the directives which
+// could be read ignoring unknown ones.
+func getParseGoMod(m *msg.Request) *msg.Response {
+	res := newResponse()
+	data := []byte(m.Content)
+	file, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		if !setModErrors(res, err) {
+			return res
+		}
+
+		if file, err = modfile.ParseLax("go.mod", data, nil); err != nil {
+			return res
+		}
+	} else {
+		res.Status = msg.Ok
+	}
+
+	mod := &msg.ModFile{
+		Go:        getModGo(file.Go),
+		Toolchain: getModToolchain(file.Toolchain),
+	}
+
+	if file.Module != nil {
+		mod.Module = &msg.ModLine{
+			Path:       file.Module.Mod.Path,
+			Deprecated: file.Module.Deprecated,
+			Start:      getModPosition(file.Module.Syntax.Start),
+			End:        getModPosition(file.Module.Syntax.End),
+		}
+	}
+
+	for _, r := range file.Require {
+		mod.Require = append(mod.Require, &msg.ModLine{
+			Path:     r.Mod.Path,
+			Version:  r.Mod.Version,
+			Indirect: r.Indirect,
+			Start:    getModPosition(r.Syntax.Start),
+			End:      getModPosition(r.Syntax.End),
+		})
+	}
+
+	for _, e := range file.Exclude {
+		mod.Exclude = append(mod.Exclude, &msg.ModLine{
+			Path:    e.Mod.Path,
+			Version: e.Mod.Version,
+			Start:   getModPosition(e.Syntax.Start),
+			End:     getModPosition(e.Syntax.End),
+		})
+	}
+
+	for _, r := range file.Retract {
+		mod.Retract = append(mod.Retract, &msg.ModRetract{
+			Low:       r.Low,
+			High:      r.High,
+			Rationale: r.Rationale,
+			Start:     getModPosition(r.Syntax.Start),
+			End:       getModPosition(r.Syntax.End),
+		})
+	}
+
+	mod.Replace = getModReplaces(file.Replace)
+	res.ModFile = mod
+	return res
+}
+
+// getParseGoWork replies a msg.ParseGoWork request with the directives of the go.work content.
+// Errors are replied with msg.Error status.
+func getParseGoWork(m *msg.Request) *msg.Response {
+	res := newResponse()
+	file, err := modfile.ParseWork("go.work", []byte(m.Content), nil)
+	if err != nil {
+		setModErrors(res, err)
+		return res
+	}
+
+	res.Status = msg.Ok
+	work := &msg.ModFile{
+		Go:        getModGo(file.Go),
+		Toolchain: getModToolchain(file.Toolchain),
+		Replace:   getModReplaces(file.Replace),
+	}
+
+	for _, u := range file.Use {
+		work.Use = append(work.Use, &msg.ModLine{
+			Path:  u.Path,
+			Start: getModPosition(u.Syntax.Start),
+			End:   getModPosition(u.Syntax.End),
+		})
+	}
+
+	res.ModFile = work
+	return res
+}
+
+// getParseGoSum replies a msg.ParseGoSum request with the lines of the go.sum content. Malformed
+// lines are replied as errors with msg.Error status.
+func getParseGoSum(m *msg.Request) *msg.Response {
+	res := newResponse()
+	res.Status = msg.Ok
+	res.GoSum = []*msg.SumLine{}
+
+	offset := 0
+	for i, line := range strings.SplitAfter(m.Content, "\n") {
+		start := msg.Position{Offset: offset, Line: i + 1, Column: 1}
+		offset += len(line)
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) != 3 {
+			res.Status = msg.Error
+			res.Errors = append(res.Errors, fmt.Sprintf("go.sum:%d: malformed line: want 3 fields, got %d", i+1, len(fields)))
+			continue
+		}
+
+		res.GoSum = append(res.GoSum, &msg.SumLine{
+			Path:    fields[0],
+			Version: strings.TrimSuffix(fields[1], "/go.mod"),
+			GoMod:   strings.HasSuffix(fields[1], "/go.mod"),
+			Hash:    fields[2],
+			Start:   start,
+		})
+	}
+
+	return res
+}
+
+// setModErrors sets the errors of a modfile parser in the response. It returns false if the
+// error isn't a list of syntax errors, so the response is fatal.
+func setModErrors(res *msg.Response, err error) bool {
+	errList, ok := err.(modfile.ErrorList)
+	if !ok {
+		res.Status = msg.Fatal
+		res.Errors = []string{err.Error()}
+		return false
+	}
+
+	res.Status = msg.Error
+	res.Errors = make([]string, 0, len(errList))
+	for _, e := range errList {
+		res.Errors = append(res.Errors, getModError(e))
+	}
+
+	return true
+}
+
+// getModError formats a modfile.Error as the scanner errors are: file:line:column: message.
+func getModError(e modfile.Error) string {
+	if e.Pos.Line == 0 {
+		return e.Error()
+	}
+
+	filename, pos := e.Filename, e.Pos
+	e.Filename, e.Pos = "", modfile.Position{}
+	return fmt.Sprintf("%s:%d:%d: %s", filename, pos.Line, pos.LineRune, e.Error())
+}
+
+// getModGo converts a go directive in a msg.ModLine.
+func getModGo(g *modfile.Go) *msg.ModLine {
+	if g == nil {
+		return nil
+	}
+
+	return &msg.ModLine{
+		Version: g.Version,
+		Start:   getModPosition(g.Syntax.Start),
+		End:     getModPosition(g.Syntax.End),
+	}
+}
+
+// getModToolchain converts a toolchain directive in a msg.ModLine.
+func getModToolchain(t *modfile.Toolchain) *msg.ModLine {
+	if t == nil {
+		return nil
+	}
+
+	return &msg.ModLine{
+		Version: t.Name,
+		Start:   getModPosition(t.Syntax.Start),
+		End:     getModPosition(t.Syntax.End),
+	}
+}
+
+// getModReplaces converts the replace directives in a []*msg.ModReplace.
+func getModReplaces(replaces []*modfile.Replace) []*msg.ModReplace {
+	var list []*msg.ModReplace
+	for _, r := range replaces {
+		list = append(list, &msg.ModReplace{
+			Old:   msg.ModVersion{Path: r.Old.Path, Version: r.Old.Version},
+			New:   msg.ModVersion{Path: r.New.Path, Version: r.New.Version},
+			Start: getModPosition(r.Syntax.Start),
+			End:   getModPosition(r.Syntax.End),
+		})
+	}
+
+	return list
+}
+
+// getModPosition converts a modfile.Position in a msg.Position.
+func getModPosition(pos modfile.Position) msg.Position {
+	return msg.Position{
+		Offset: pos.Byte,
+		Line:   pos.Line,
+		Column: pos.LineRune,
+	}
+}
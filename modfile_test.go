@@ -0,0 +1,130 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetParseGoMod(t *testing.T) {
+	source := `// Deprecated: use example.com/v2.
+module example.com/foo
+
+go 1.21
+
+toolchain go1.21.3
+
+require (
+	github.com/pkg/errors v0.9.1
+	golang.org/x/mod v0.14.0 // indirect
+)
+
+exclude github.com/pkg/errors v0.9.0
+
+replace github.com/pkg/errors => ../errors
+
+retract [v1.0.0, v1.0.5] // broken build
+`
+	res := getResponse(&msg.Request{Action: msg.ParseGoMod, Content: source})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Nil(t, res.AST)
+
+	mod := res.ModFile
+	require.Equal(t, &msg.ModLine{
+		Path:       "example.com/foo",
+		Deprecated: "use example.com/v2.",
+		Start:      msg.Position{Offset: 35, Line: 2, Column: 1},
+		End:        msg.Position{Offset: 57, Line: 2, Column: 23},
+	}, mod.Module)
+	require.Equal(t, "1.21", mod.Go.Version)
+	require.Equal(t, 4, mod.Go.Start.Line)
+	require.Equal(t, "go1.21.3", mod.Toolchain.Version)
+
+	require.Len(t, mod.Require, 2)
+	require.Equal(t, "github.com/pkg/errors", mod.Require[0].Path)
+	require.Equal(t, "v0.9.1", mod.Require[0].Version)
+	require.False(t, mod.Require[0].Indirect)
+	require.Equal(t, msg.Position{Offset: 99, Line: 9, Column: 2}, mod.Require[0].Start)
+	require.True(t, mod.Require[1].Indirect)
+
+	require.Len(t, mod.Exclude, 1)
+	require.Equal(t, "v0.9.0", mod.Exclude[0].Version)
+
+	require.Len(t, mod.Replace, 1)
+	require.Equal(t, msg.ModVersion{Path: "github.com/pkg/errors"}, mod.Replace[0].Old)
+	require.Equal(t, msg.ModVersion{Path: "../errors"}, mod.Replace[0].New)
+
+	require.Len(t, mod.Retract, 1)
+	require.Equal(t, "v1.0.0", mod.Retract[0].Low)
+	require.Equal(t, "v1.0.5", mod.Retract[0].High)
+	require.Equal(t, "broken build", mod.Retract[0].Rationale)
+}
+
+func TestGetParseGoModErrors(t *testing.T) {
+	source := "module example.com/foo\n\nrequire github.com/pkg/errors latest\n\nfoo bar\n"
+	res := getResponse(&msg.Request{Action: msg.ParseGoMod, Content: source})
+	require.Equal(t, msg.Error, res.Status)
+	require.Equal(t, []string{
+		"go.mod:3:1: require github.com/pkg/errors: version \"latest\" invalid: must be of the form v1.2.3",
+		"go.mod:5:1: unknown directive: foo",
+	}, res.Errors)
+	require.Nil(t, res.ModFile)
+
+	res = getResponse(&msg.Request{Action: msg.ParseGoMod, Content: "module example.com/foo\n\nfoo bar\n"})
+	require.Equal(t, msg.Error, res.Status)
+	require.Equal(t, []string{"go.mod:3:1: unknown directive: foo"}, res.Errors)
+	require.Equal(t, "example.com/foo", res.ModFile.Module.Path)
+
+	res = getResponse(&msg.Request{Action: msg.ParseGoMod, Content: "module (\n"})
+	require.Equal(t, msg.Error, res.Status)
+	require.NotEmpty(t, res.Errors)
+	require.Nil(t, res.ModFile)
+}
+
+func TestGetParseGoWork(t *testing.T) {
+	source := "go 1.22\n\nuse (\n\t./foo\n\t./bar\n)\n\nreplace example.com/foo v1.0.0 => example.com/baz v1.1.0\n"
+	res := getResponse(&msg.Request{Action: msg.ParseGoWork, Content: source})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Equal(t, "1.22", res.ModFile.Go.Version)
+	require.Len(t, res.ModFile.Use, 2)
+	require.Equal(t, "./foo", res.ModFile.Use[0].Path)
+	require.Equal(t, msg.Position{Offset: 16, Line: 4, Column: 2}, res.ModFile.Use[0].Start)
+	require.Equal(t, []*msg.ModReplace{{
+		Old:   msg.ModVersion{Path: "example.com/foo", Version: "v1.0.0"},
+		New:   msg.ModVersion{Path: "example.com/baz", Version: "v1.1.0"},
+		Start: msg.Position{Offset: 32, Line: 8, Column: 1},
+		End:   msg.Position{Offset: 88, Line: 8, Column: 57},
+	}}, res.ModFile.Replace)
+	require.Nil(t, res.ModFile.Module)
+
+	res = getResponse(&msg.Request{Action: msg.ParseGoWork, Content: "module foo\n"})
+	require.Equal(t, msg.Error, res.Status)
+	require.Equal(t, []string{"go.work:1:1: unknown directive: module"}, res.Errors)
+}
+
+func TestGetParseGoSum(t *testing.T) {
+	source := "github.com/pkg/errors v0.9.1 h1:FEBLx1zS214owpjy7qsBeixbURkuhQAwrK5UwLGTwt4=\n" +
+		"github.com/pkg/errors v0.9.1/go.mod h1:bwawxfHBFNV+L2hUp1rHADufV3IMtnDRdf1r5NINEl0=\n" +
+		"\n" +
+		"broken line\n"
+	res := getResponse(&msg.Request{Action: msg.ParseGoSum, Content: source})
+	require.Equal(t, msg.Error, res.Status)
+	require.Equal(t, []string{"go.sum:4: malformed line: want 3 fields, got 2"}, res.Errors)
+	require.Equal(t, []*msg.SumLine{
+		{
+			Path:    "github.com/pkg/errors",
+			Version: "v0.9.1",
+			Hash:    "h1:FEBLx1zS214owpjy7qsBeixbURkuhQAwrK5UwLGTwt4=",
+			Start:   msg.Position{Offset: 0, Line: 1, Column: 1},
+		},
+		{
+			Path:    "github.com/pkg/errors",
+			Version: "v0.9.1",
+			GoMod:   true,
+			Hash:    "h1:bwawxfHBFNV+L2hUp1rHADufV3IMtnDRdf1r5NINEl0=",
+			Start:   msg.Position{Offset: 77, Line: 2, Column: 1},
+		},
+	}, res.GoSum)
+}
@@ -0,0 +1,28 @@
+package msg
+
+// APIChange is a change of the exported API between the old and the new sources of an APIDiff
+// request.
+type APIChange struct {
+	// Symbol is the name of the changed symbol: the name of a package-level declaration, or T.M and
+	// T.F for the methods and fields of a type T. Embedded interfaces are named after their type,
+	// i.e. T.io.Reader.
+	Symbol string `codec:"symbol" json:"symbol"`
+	// Kind is the symbol kind: func, method, type, field, embedded, var or const.
+	Kind string `codec:"kind" json:"kind"`
+	// Change is the kind of change: added, removed or changed.
+	Change string `codec:"change" json:"change"`
+	// Compatible reports whether the code using the old API keeps compiling with the new one.
+	Compatible bool `codec:"compatible" json:"compatible"`
+	// Message describes the change, i.e. "signature changed".
+	Message string `codec:"message" json:"message"`
+	// Old is the symbol in the old sources. It is nil if it was added.
+	Old *APIPosition `codec:"old,omitempty" json:"old,omitempty"`
+	// New is the symbol in the new sources. It is nil if it was removed.
+	New *APIPosition `codec:"new,omitempty" json:"new,omitempty"`
+}
+
+// APIPosition is the position of a symbol and the file which declares it.
+type APIPosition struct {
+	File  string   `codec:"file" json:"file"`
+	Start Position `codec:"start" json:"start"`
+}
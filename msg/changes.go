@@ -0,0 +1,19 @@
+package msg
+
+// DeclChange is a function, method or type declaration which changed between the old and the new
+// content of a Changes request.
+type DeclChange struct {
+	Name string `codec:"name" json:"name"`
+	// Kind is the declaration kind: func, method or type.
+	Kind string `codec:"kind" json:"kind"`
+	// Recv is the receiver type of a method, i.e. *T.
+	Recv string `codec:"recv,omitempty" json:"recv,omitempty"`
+	// Change is the kind of change: added, removed, signature or body for functions and methods, and
+	// added, removed or definition for types. A function whose signature changed is reported as
+	// such even if its body changed too.
+	Change string `codec:"change" json:"change"`
+	// Old is the position of the declaration in the old content. It is nil if it was added.
+	Old *Position `codec:"old,omitempty" json:"old,omitempty"`
+	// New is the position of the declaration in the new content. It is nil if it was removed.
+	New *Position `codec:"new,omitempty" json:"new,omitempty"`
+}
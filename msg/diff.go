@@ -0,0 +1,24 @@
+package msg
+
+// EditOp is an operation of the edit script of a Diff request, which turns the old AST into the
+// new one.
+type EditOp struct {
+	// Op is the operation: insert, delete, update or move. Inserted and deleted subtrees are
+	// reported only by their root.
+	Op string `codec:"op" json:"op"`
+	// Node is the AST node type, i.e. CallExpr.
+	Node string `codec:"node" json:"node"`
+	// Old is the node in the old content. It is nil for insert operations.
+	Old *DiffNode `codec:"old,omitempty" json:"old,omitempty"`
+	// New is the node in the new content. It is nil for delete operations.
+	New *DiffNode `codec:"new,omitempty" json:"new,omitempty"`
+}
+
+// DiffNode is the location and the label of a node on one side of a Diff request.
+type DiffNode struct {
+	Start Position `codec:"start" json:"start"`
+	End   Position `codec:"end" json:"end"`
+	// Label is the value of the node, if it has one: the name of an identifier, the value of a
+	// literal or the operator of an expression or a statement.
+	Label string `codec:"label,omitempty" json:"label,omitempty"`
+}
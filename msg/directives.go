@@ -0,0 +1,37 @@
+package msg
+
+// FileDirectives is the result of a Directives request.
+type FileDirectives struct {
+	List []*Directive `codec:"list,omitempty" json:"list,omitempty"`
+	// Constraint is the build constraint of the file: its //go:build line or, if there isn't one, the
+	// conjunction of its // +build lines. It is nil if the file has no build constraints.
+	Constraint *Constraint `codec:"constraint,omitempty" json:"constraint,omitempty"`
+	// Satisfied reports whether Constraint is satisfied by the tags of the request. Files without
+	// build constraints are always satisfied.
+	Satisfied bool `codec:"satisfied" json:"satisfied"`
+}
+
+// Directive is a //go: directive or a // +build line found in the comments of the content.
+type Directive struct {
+	// Name is the directive name, i.e. go:build, go:generate, go:embed or +build.
+	Name string `codec:"name" json:"name"`
+	// Args are the space-separated arguments of the directive.
+	Args []string `codec:"args,omitempty" json:"args,omitempty"`
+	// Text is the whole comment text.
+	Text  string   `codec:"text" json:"text"`
+	Start Position `codec:"start" json:"start"`
+	// Constraint is the parsed expression of a build constraint line.
+	Constraint *Constraint `codec:"constraint,omitempty" json:"constraint,omitempty"`
+}
+
+// Constraint is a node of a build constraint expression.
+type Constraint struct {
+	// Op is the node kind: and, or, not or tag.
+	Op string `codec:"op" json:"op"`
+	// Tag is the build tag of a tag node.
+	Tag string `codec:"tag,omitempty" json:"tag,omitempty"`
+	// X is the operand of a not node and the left operand of and and or nodes.
+	X *Constraint `codec:"x,omitempty" json:"x,omitempty"`
+	// Y is the right operand of and and or nodes.
+	Y *Constraint `codec:"y,omitempty" json:"y,omitempty"`
+}
@@ -0,0 +1,11 @@
+package msg
+
+// Formatted is the result of a Format request.
+type Formatted struct {
+	// Source is the content formatted as gofmt does.
+	Source string `codec:"source" json:"source"`
+	// Changed reports whether Source differs from the requested content.
+	Changed bool `codec:"changed" json:"changed"`
+	// Diff is the unified diff from the requested content to Source. It is empty if nothing changed.
+	Diff string `codec:"diff,omitempty" json:"diff,omitempty"`
+}
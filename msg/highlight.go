@@ -0,0 +1,9 @@
+package msg
+
+// HighlightSpan is a span of the content classified for syntax highlighting. Kind is keyword, type,
+// function, method, parameter, field, label, package, string, number or comment.
+type HighlightSpan struct {
+	Kind  string   `codec:"kind" json:"kind"`
+	Start Position `codec:"start" json:"start"`
+	End   Position `codec:"end" json:"end"`
+}
@@ -0,0 +1,21 @@
+package msg
+
+// Identifiers are the identifiers of the AST split in sub-tokens, as requested by
+// Request.Identifiers.
+type Identifiers struct {
+	// List has every identifier of the AST in source order.
+	List []*Identifier `codec:"list,omitempty" json:"list,omitempty"`
+	// Bag counts the normalized sub-tokens of all the identifiers of the file.
+	Bag map[string]int `codec:"bag,omitempty" json:"bag,omitempty"`
+}
+
+// Identifier is an *ast.Ident of the AST with its sub-tokens.
+type Identifier struct {
+	Name  string   `codec:"name" json:"name"`
+	Start Position `codec:"start" json:"start"`
+	// Tokens are the sub-tokens of the name, i.e. parseHTTPRequest2 is split in parse, HTTP, Request
+	// and 2. The blank identifier has no tokens.
+	Tokens []string `codec:"tokens,omitempty" json:"tokens,omitempty"`
+	// Normalized are the tokens in lower case.
+	Normalized []string `codec:"normalized,omitempty" json:"normalized,omitempty"`
+}
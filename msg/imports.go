@@ -0,0 +1,40 @@
+package msg
+
+// ImportOptions are the import edits of an Imports request. They are applied in the order of the
+// fields, so imports added by the request are never deleted as unused.
+type ImportOptions struct {
+	Rewrite      []*ImportRewrite `codec:"rewrite,omitempty" json:"rewrite,omitempty"`
+	DeleteUnused bool             `codec:"delete_unused,omitempty" json:"delete_unused,omitempty"`
+	Add          []*ImportSpec    `codec:"add,omitempty" json:"add,omitempty"`
+	// Group sorts the imports of every import declaration, with the standard library imports in a
+	// group before the other ones.
+	Group bool `codec:"group,omitempty" json:"group,omitempty"`
+}
+
+// ImportRewrite replaces the path of an import.
+type ImportRewrite struct {
+	Old string `codec:"old" json:"old"`
+	New string `codec:"new" json:"new"`
+}
+
+// ImportSpec is an import to add, with an optional alias.
+type ImportSpec struct {
+	Name string `codec:"name,omitempty" json:"name,omitempty"`
+	Path string `codec:"path" json:"path"`
+}
+
+// FixedImports is the result of an Imports request.
+type FixedImports struct {
+	// Source is the content with the import edits applied, formatted as gofmt does.
+	Source  string          `codec:"source" json:"source"`
+	Changes []*ImportChange `codec:"changes" json:"changes"`
+}
+
+// ImportChange is an import edit which changed the content. Change is added, deleted, rewritten or
+// grouped. Grouped changes have no path, and Old is only set in rewritten ones.
+type ImportChange struct {
+	Change string `codec:"change" json:"change"`
+	Name   string `codec:"name,omitempty" json:"name,omitempty"`
+	Path   string `codec:"path,omitempty" json:"path,omitempty"`
+	Old    string `codec:"old,omitempty" json:"old,omitempty"`
+}
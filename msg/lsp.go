@@ -0,0 +1,50 @@
+package msg
+
+// LSPPosition is a position as the Language Server Protocol defines it: a zero-based line and the
+// character in the line, counted in UTF-16 code units.
+type LSPPosition struct {
+	Line      int `codec:"line" json:"line"`
+	Character int `codec:"character" json:"character"`
+}
+
+// LSPRange is a range of LSP positions. End is exclusive.
+type LSPRange struct {
+	Start LSPPosition `codec:"start" json:"start"`
+	End   LSPPosition `codec:"end" json:"end"`
+}
+
+// FoldingRange is an LSP FoldingRange: a region of the content which can be folded.
+type FoldingRange struct {
+	StartLine      int  `codec:"startLine" json:"startLine"`
+	StartCharacter *int `codec:"startCharacter,omitempty" json:"startCharacter,omitempty"`
+	EndLine        int  `codec:"endLine" json:"endLine"`
+	EndCharacter   *int `codec:"endCharacter,omitempty" json:"endCharacter,omitempty"`
+	// Kind is comment, imports or region. It is empty for code blocks.
+	Kind string `codec:"kind,omitempty" json:"kind,omitempty"`
+}
+
+// SelectionRange is an LSP SelectionRange: a range to select and the range which contains it, to
+// expand the selection.
+type SelectionRange struct {
+	Range  LSPRange        `codec:"range" json:"range"`
+	Parent *SelectionRange `codec:"parent,omitempty" json:"parent,omitempty"`
+}
+
+// DocumentSymbol is an LSP DocumentSymbol: a top-level declaration of a document.
+type DocumentSymbol struct {
+	Name   string `codec:"name" json:"name"`
+	Detail string `codec:"detail,omitempty" json:"detail,omitempty"`
+	// Kind is an LSP SymbolKind, i.e. 12 for functions.
+	Kind           int      `codec:"kind" json:"kind"`
+	Range          LSPRange `codec:"range" json:"range"`
+	SelectionRange LSPRange `codec:"selectionRange" json:"selectionRange"`
+}
+
+// Diagnostic is an LSP Diagnostic: an error found in a document.
+type Diagnostic struct {
+	Range LSPRange `codec:"range" json:"range"`
+	// Severity is an LSP DiagnosticSeverity, 1 for errors.
+	Severity int    `codec:"severity" json:"severity"`
+	Source   string `codec:"source,omitempty" json:"source,omitempty"`
+	Message  string `codec:"message" json:"message"`
+}
@@ -11,6 +11,63 @@ const (
 	Fatal = "fatal"
 	// ParseAst is the Action identifier to parse an AST.
 	ParseAst = "ParseAST"
+	// Format is the Action identifier to format the content with gofmt.
+	Format = "Format"
+	// TypeCheck is the Action identifier to parse an AST and get its type information.
+	TypeCheck = "TypeCheck"
+	// ParsePackage is the Action identifier to parse the ASTs of several files of the same package.
+	ParsePackage = "ParsePackage"
+	// Tokenize is the Action identifier to get the lexical tokens of the content.
+	Tokenize = "Tokenize"
+	// Outline is the Action identifier to get the top-level declarations of the content.
+	Outline = "Outline"
+	// Metrics is the Action identifier to get the code metrics of the functions of the content.
+	Metrics = "Metrics"
+	// ListTests is the Action identifier to find the test, benchmark, fuzz and example functions.
+	ListTests = "ListTests"
+	// Directives is the Action identifier to get the compiler directives and build constraints.
+	Directives = "Directives"
+	// ParseGoMod is the Action identifier to parse a go.mod file.
+	ParseGoMod = "ParseGoMod"
+	// ParseGoWork is the Action identifier to parse a go.work file.
+	ParseGoWork = "ParseGoWork"
+	// ParseGoSum is the Action identifier to parse a go.sum file.
+	ParseGoSum = "ParseGoSum"
+	// ParseTemplate is the Action identifier to parse a text/template or html/template source.
+	ParseTemplate = "ParseTemplate"
+	// PathContexts is the Action identifier to extract the leaf-to-leaf AST paths of the functions.
+	PathContexts = "PathContexts"
+	// Diff is the Action identifier to get the structural differences between two sources.
+	Diff = "Diff"
+	// Changes is the Action identifier to get the declarations which changed between two sources.
+	Changes = "Changes"
+	// APIDiff is the Action identifier to check the compatibility of the exported API of two sources.
+	APIDiff = "APIDiff"
+	// Query is the Action identifier to select the AST nodes which match a query.
+	Query = "Query"
+	// NodeAt is the Action identifier to get the AST nodes enclosing a position or a range.
+	NodeAt = "NodeAt"
+	// FoldingRanges is the Action identifier to get the regions of the content which can be folded.
+	FoldingRanges = "FoldingRanges"
+	// SelectionRanges is the Action identifier to get the ranges to expand the selection at positions.
+	SelectionRanges = "SelectionRanges"
+	// Highlight is the Action identifier to classify the tokens of the content for syntax highlighting.
+	Highlight = "Highlight"
+	// Rename is the Action identifier to rename an identifier and its references in the content.
+	Rename = "Rename"
+	// Imports is the Action identifier to add, delete, rewrite and group the imports of the content.
+	Imports = "Imports"
+)
+
+const (
+	// ModeFile parses the content as a whole source file. It is the default mode.
+	ModeFile = "file"
+	// ModeExpr parses the content as a single expression.
+	ModeExpr = "expr"
+	// ModeStmts parses the content as a list of statements.
+	ModeStmts = "stmts"
+	// ModeDecls parses the content as a list of declarations.
+	ModeDecls = "decls"
 )
 
 // Request is the message the driver receives. It marshals to Messagepack.
@@ -19,14 +76,83 @@ type Request struct {
 	Language        string `codec:"language,omitempty" json:"language,omitempty"`
 	LanguageVersion string `codec:"language_version,omitempty" json:"language_version,omitempty"`
 	Content         string `codec:"content" json:"content"`
+	// Old is the previous version of Content in Diff and Changes requests.
+	Old string `codec:"old,omitempty" json:"old,omitempty"`
+	// Files are the sources of a multi-file request. Content is ignored in that case.
+	Files []*SourceFile `codec:"files,omitempty" json:"files,omitempty"`
+	// OldFiles are the previous version of Files in an APIDiff request.
+	OldFiles []*SourceFile `codec:"old_files,omitempty" json:"old_files,omitempty"`
+	// Archive reports whether Content is a txtar archive bundling several files. Only ParseAst and
+	// ParsePackage requests accept archives.
+	Archive bool `codec:"archive,omitempty" json:"archive,omitempty"`
+	// Mode is the parser mode of a ParseAst request: ModeFile, ModeExpr, ModeStmts or ModeDecls.
+	Mode string `codec:"mode,omitempty" json:"mode,omitempty"`
+	// Comments includes the comments in the msg.Tokenize response.
+	Comments bool `codec:"comments,omitempty" json:"comments,omitempty"`
+	// Tags are the build tags the build constraints are evaluated against.
+	Tags []string `codec:"tags,omitempty" json:"tags,omitempty"`
+	// Funcs are the names of the custom functions a msg.ParseTemplate source can call.
+	Funcs []string `codec:"funcs,omitempty" json:"funcs,omitempty"`
+	// Identifiers annotates the identifiers of a ParseAst response with their sub-tokens, in every
+	// mode. Other actions and archives don't accept it.
+	Identifiers bool `codec:"identifiers,omitempty" json:"identifiers,omitempty"`
+	// Paths configures a PathContexts request. The defaults are used if it is nil.
+	Paths *PathOptions `codec:"paths,omitempty" json:"paths,omitempty"`
+	// Query is the selector of a Query request, i.e. //CallExpr[Fun:SelectorExpr[@Sel.Name="Exec"]].
+	Query string `codec:"query,omitempty" json:"query,omitempty"`
+	// Source includes the source of every match in a Query response.
+	Source bool `codec:"source,omitempty" json:"source,omitempty"`
+	// Start is the position of a NodeAt request, or of the identifier of a Rename request. It is
+	// given by its line and column if Line is set, or by its offset otherwise. Columns are counted in
+	// bytes.
+	Start *Position `codec:"start,omitempty" json:"start,omitempty"`
+	// End makes the position of a NodeAt request a range. It is resolved as Start.
+	End *Position `codec:"end,omitempty" json:"end,omitempty"`
+	// Positions are the positions of a SelectionRanges request, resolved as Start.
+	Positions []*Position `codec:"positions,omitempty" json:"positions,omitempty"`
+	// NewName is the new name of the identifier of a Rename request.
+	NewName string `codec:"new_name,omitempty" json:"new_name,omitempty"`
+	// Imports are the import edits of an Imports request.
+	Imports *ImportOptions `codec:"imports,omitempty" json:"imports,omitempty"`
 }
 
 // Response is the replied message. It marshals to Messagepack.
+//
+// Generated reports whether the content is generated code, with the ast.IsGenerated semantics, and
+// Generator is the generator named in the "// Code generated ... DO NOT EDIT." comment, if any. They
+// are only set for requests whose content is a Go source, so not for ParsePackage, ParseGoMod,
+// ParseGoWork, ParseGoSum, ParseTemplate and archives.
 type Response struct {
-	Status          string    `codec:"status" json:"status"`
-	Errors          []string  `codec:"errors,omitempty" json:"errors,omitempty"`
-	Driver          string    `codec:"driver" json:"driver"`
-	Language        string    `codec:"language" json:"language"`
-	LanguageVersion string    `codec:"language_version" json:"language_version"`
-	AST             *ast.File `codec:"ast" json:"ast"`
+	Status          string            `codec:"status" json:"status"`
+	Errors          []string          `codec:"errors,omitempty" json:"errors,omitempty"`
+	Driver          string            `codec:"driver" json:"driver"`
+	Language        string            `codec:"language" json:"language"`
+	LanguageVersion string            `codec:"language_version" json:"language_version"`
+	AST             *ast.File         `codec:"ast" json:"ast"`
+	Generated       bool              `codec:"generated,omitempty" json:"generated,omitempty"`
+	Generator       string            `codec:"generator,omitempty" json:"generator,omitempty"`
+	Format          *Formatted        `codec:"format,omitempty" json:"format,omitempty"`
+	TypeInfo        *TypeInfo         `codec:"type_info,omitempty" json:"type_info,omitempty"`
+	Package         *Package          `codec:"package,omitempty" json:"package,omitempty"`
+	Tokens          []*Token          `codec:"tokens,omitempty" json:"tokens,omitempty"`
+	Snippet         *Snippet          `codec:"snippet,omitempty" json:"snippet,omitempty"`
+	Outline         *FileOutline      `codec:"outline,omitempty" json:"outline,omitempty"`
+	Metrics         *CodeMetrics      `codec:"metrics,omitempty" json:"metrics,omitempty"`
+	Tests           []*TestFunc       `codec:"tests,omitempty" json:"tests,omitempty"`
+	Directives      *FileDirectives   `codec:"directives,omitempty" json:"directives,omitempty"`
+	ModFile         *ModFile          `codec:"mod_file,omitempty" json:"mod_file,omitempty"`
+	GoSum           []*SumLine        `codec:"go_sum,omitempty" json:"go_sum,omitempty"`
+	Templates       []*Template       `codec:"templates,omitempty" json:"templates,omitempty"`
+	Identifiers     *Identifiers      `codec:"identifiers,omitempty" json:"identifiers,omitempty"`
+	PathContexts    []*FuncPaths      `codec:"path_contexts,omitempty" json:"path_contexts,omitempty"`
+	Edits           []*EditOp         `codec:"edits,omitempty" json:"edits,omitempty"`
+	Changes         []*DeclChange     `codec:"changes,omitempty" json:"changes,omitempty"`
+	APIChanges      []*APIChange      `codec:"api_changes,omitempty" json:"api_changes,omitempty"`
+	Matches         []*QueryMatch     `codec:"matches,omitempty" json:"matches,omitempty"`
+	NodePath        *NodePath         `codec:"node_path,omitempty" json:"node_path,omitempty"`
+	FoldingRanges   []*FoldingRange   `codec:"folding_ranges,omitempty" json:"folding_ranges,omitempty"`
+	SelectionRanges []*SelectionRange `codec:"selection_ranges,omitempty" json:"selection_ranges,omitempty"`
+	Highlight       []*HighlightSpan  `codec:"highlight,omitempty" json:"highlight,omitempty"`
+	Rename          *Renamed          `codec:"rename,omitempty" json:"rename,omitempty"`
+	Imports         *FixedImports     `codec:"imports,omitempty" json:"imports,omitempty"`
 }
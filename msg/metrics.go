@@ -0,0 +1,40 @@
+package msg
+
+// CodeMetrics is the result of a Metrics request.
+type CodeMetrics struct {
+	Functions []*FuncMetrics `codec:"functions,omitempty" json:"functions,omitempty"`
+	Total     *FileMetrics   `codec:"total" json:"total"`
+}
+
+// FuncMetrics are the code metrics of a function or method declaration.
+type FuncMetrics struct {
+	Name string `codec:"name" json:"name"`
+	// Recv is the receiver type of a method, i.e. *T.
+	Recv  string   `codec:"recv,omitempty" json:"recv,omitempty"`
+	Start Position `codec:"start" json:"start"`
+	End   Position `codec:"end" json:"end"`
+	// Cyclomatic is the McCabe cyclomatic complexity.
+	Cyclomatic int `codec:"cyclomatic" json:"cyclomatic"`
+	// Cognitive is the cognitive complexity as defined by SonarSource.
+	Cognitive int `codec:"cognitive" json:"cognitive"`
+	// Nesting is the maximum nesting depth of control structures and function literals.
+	Nesting int `codec:"nesting" json:"nesting"`
+	Params  int `codec:"params" json:"params"`
+	Results int `codec:"results" json:"results"`
+	// HalsteadVolume is computed from the tokens of the declaration: identifiers and literals are
+	// operands, every other token is an operator.
+	HalsteadVolume float64 `codec:"halstead_volume" json:"halstead_volume"`
+	// SLOC is the number of lines with code, skipping blank and comment-only lines.
+	SLOC int `codec:"sloc" json:"sloc"`
+}
+
+// FileMetrics are the code metrics of the whole content. Complexities are the sum of the functions.
+type FileMetrics struct {
+	Functions      int     `codec:"functions" json:"functions"`
+	Cyclomatic     int     `codec:"cyclomatic" json:"cyclomatic"`
+	Cognitive      int     `codec:"cognitive" json:"cognitive"`
+	Nesting        int     `codec:"nesting" json:"nesting"`
+	HalsteadVolume float64 `codec:"halstead_volume" json:"halstead_volume"`
+	SLOC           int     `codec:"sloc" json:"sloc"`
+	Lines          int     `codec:"lines" json:"lines"`
+}
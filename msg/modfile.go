@@ -0,0 +1,62 @@
+package msg
+
+// ModFile is the result of a ParseGoMod or a ParseGoWork request. go.work files only have Go,
+// Toolchain, Use and Replace directives.
+type ModFile struct {
+	// Module is the module directive. Path is the module path and Deprecated its deprecation message.
+	Module *ModLine `codec:"module,omitempty" json:"module,omitempty"`
+	// Go is the go directive. Version is the language version, i.e. 1.21.
+	Go *ModLine `codec:"go,omitempty" json:"go,omitempty"`
+	// Toolchain is the toolchain directive. Version is the toolchain name, i.e. go1.21.0.
+	Toolchain *ModLine      `codec:"toolchain,omitempty" json:"toolchain,omitempty"`
+	Require   []*ModLine    `codec:"require,omitempty" json:"require,omitempty"`
+	Exclude   []*ModLine    `codec:"exclude,omitempty" json:"exclude,omitempty"`
+	Replace   []*ModReplace `codec:"replace,omitempty" json:"replace,omitempty"`
+	Retract   []*ModRetract `codec:"retract,omitempty" json:"retract,omitempty"`
+	// Use are the use directives of a go.work file. Path is the module directory.
+	Use []*ModLine `codec:"use,omitempty" json:"use,omitempty"`
+}
+
+// ModLine is a directive of a go.mod or go.work file.
+type ModLine struct {
+	Path    string `codec:"path,omitempty" json:"path,omitempty"`
+	Version string `codec:"version,omitempty" json:"version,omitempty"`
+	// Indirect reports whether a requirement has the "// indirect" comment.
+	Indirect   bool     `codec:"indirect,omitempty" json:"indirect,omitempty"`
+	Deprecated string   `codec:"deprecated,omitempty" json:"deprecated,omitempty"`
+	Start      Position `codec:"start" json:"start"`
+	End        Position `codec:"end" json:"end"`
+}
+
+// ModVersion is a module path and an optional version.
+type ModVersion struct {
+	Path    string `codec:"path" json:"path"`
+	Version string `codec:"version,omitempty" json:"version,omitempty"`
+}
+
+// ModReplace is a replace directive.
+type ModReplace struct {
+	Old   ModVersion `codec:"old" json:"old"`
+	New   ModVersion `codec:"new" json:"new"`
+	Start Position   `codec:"start" json:"start"`
+	End   Position   `codec:"end" json:"end"`
+}
+
+// ModRetract is a retract directive of a version or a closed interval of versions.
+type ModRetract struct {
+	Low       string   `codec:"low" json:"low"`
+	High      string   `codec:"high" json:"high"`
+	Rationale string   `codec:"rationale,omitempty" json:"rationale,omitempty"`
+	Start     Position `codec:"start" json:"start"`
+	End       Position `codec:"end" json:"end"`
+}
+
+// SumLine is a line of a go.sum file.
+type SumLine struct {
+	Path    string `codec:"path" json:"path"`
+	Version string `codec:"version" json:"version"`
+	// GoMod reports whether the hash is of the go.mod file of the module instead of its content.
+	GoMod bool     `codec:"go_mod,omitempty" json:"go_mod,omitempty"`
+	Hash  string   `codec:"hash" json:"hash"`
+	Start Position `codec:"start" json:"start"`
+}
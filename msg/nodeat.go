@@ -0,0 +1,18 @@
+package msg
+
+// NodePath is the result of a NodeAt request: the nodes enclosing a position or a range.
+type NodePath struct {
+	// Nodes goes from the innermost node to the *ast.File.
+	Nodes []*EnclosingNode `codec:"nodes" json:"nodes"`
+	// Exact reports whether the range matches the innermost node, or the node it was expanded to
+	// in order to include the surrounding whitespace or punctuation.
+	Exact bool `codec:"exact" json:"exact"`
+}
+
+// EnclosingNode is a node of a NodePath.
+type EnclosingNode struct {
+	// Node is the AST node type, i.e. CallExpr.
+	Node  string   `codec:"node" json:"node"`
+	Start Position `codec:"start" json:"start"`
+	End   Position `codec:"end" json:"end"`
+}
@@ -0,0 +1,32 @@
+package msg
+
+// FileOutline is the result of an Outline request: the top-level declarations of the content.
+type FileOutline struct {
+	Package string    `codec:"package" json:"package"`
+	Imports []*Import `codec:"imports,omitempty" json:"imports,omitempty"`
+	Symbols []*Symbol `codec:"symbols,omitempty" json:"symbols,omitempty"`
+}
+
+// Import is an import spec of the content.
+type Import struct {
+	// Name is the local package name, if any: an alias, "." or "_".
+	Name  string   `codec:"name,omitempty" json:"name,omitempty"`
+	Path  string   `codec:"path" json:"path"`
+	Start Position `codec:"start" json:"start"`
+	End   Position `codec:"end" json:"end"`
+}
+
+// Symbol is a top-level declaration of the content.
+type Symbol struct {
+	Name string `codec:"name" json:"name"`
+	// Kind is the symbol kind: func, method, type, const or var.
+	Kind string `codec:"kind" json:"kind"`
+	// Recv is the receiver type of a method, i.e. *T.
+	Recv string `codec:"recv,omitempty" json:"recv,omitempty"`
+	// Signature is the declaration rendered as source, without bodies nor comments.
+	Signature string   `codec:"signature" json:"signature"`
+	Exported  bool     `codec:"exported" json:"exported"`
+	Doc       string   `codec:"doc,omitempty" json:"doc,omitempty"`
+	Start     Position `codec:"start" json:"start"`
+	End       Position `codec:"end" json:"end"`
+}
@@ -0,0 +1,40 @@
+package msg
+
+import "go/ast"
+
+// SourceFile is a named source of a multi-file request.
+type SourceFile struct {
+	Name    string `codec:"name" json:"name"`
+	Content string `codec:"content" json:"content"`
+}
+
+// Package is the result of a ParsePackage request. Every file is parsed in the same file set, so the
+// positions in the ASTs don't overlap between files.
+type Package struct {
+	// Name is the package name, taken from the first package clause which isn't of an external test
+	// package.
+	Name  string         `codec:"name" json:"name"`
+	Files []*ParsedFile  `codec:"files" json:"files"`
+	Scope []*Declaration `codec:"scope,omitempty" json:"scope,omitempty"`
+}
+
+// ParsedFile is the AST of one of the files of a Package.
+type ParsedFile struct {
+	Name string `codec:"name" json:"name"`
+	// Status and Errors have the same meaning than in Response, but only for this file.
+	Status string    `codec:"status" json:"status"`
+	Errors []string  `codec:"errors,omitempty" json:"errors,omitempty"`
+	AST    *ast.File `codec:"ast" json:"ast"`
+	// Generated and Generator have the same meaning than in Response, but only for this file.
+	Generated bool   `codec:"generated,omitempty" json:"generated,omitempty"`
+	Generator string `codec:"generator,omitempty" json:"generator,omitempty"`
+}
+
+// Declaration is a package-level identifier and the file which declares it.
+type Declaration struct {
+	Name string `codec:"name" json:"name"`
+	// Kind is the declaration kind: func, type, var or const.
+	Kind  string   `codec:"kind" json:"kind"`
+	File  string   `codec:"file" json:"file"`
+	Start Position `codec:"start" json:"start"`
+}
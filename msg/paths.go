@@ -0,0 +1,33 @@
+package msg
+
+// PathOptions configures a PathContexts request. Zero values take the defaults of code2vec.
+type PathOptions struct {
+	// MaxLength is the maximum number of edges of a path. It is 8 by default.
+	MaxLength int `codec:"max_length,omitempty" json:"max_length,omitempty"`
+	// MaxWidth is the maximum distance between the children of the top node of a path which the
+	// two ends descend from. It is 2 by default.
+	MaxWidth int `codec:"max_width,omitempty" json:"max_width,omitempty"`
+	// MaxContexts is the maximum number of contexts sampled from every function. It is 200 by
+	// default; a negative value keeps all of them.
+	MaxContexts int `codec:"max_contexts,omitempty" json:"max_contexts,omitempty"`
+	// Seed seeds the sampling, so the same request always samples the same contexts.
+	Seed int64 `codec:"seed,omitempty" json:"seed,omitempty"`
+}
+
+// FuncPaths are the path contexts of a function or method declaration.
+type FuncPaths struct {
+	Name string `codec:"name" json:"name"`
+	// Recv is the receiver type of a method, i.e. *T.
+	Recv     string         `codec:"recv,omitempty" json:"recv,omitempty"`
+	Start    Position       `codec:"start" json:"start"`
+	Contexts []*PathContext `codec:"contexts,omitempty" json:"contexts,omitempty"`
+}
+
+// PathContext is the path between two leaves of the AST and the tokens of the leaves.
+type PathContext struct {
+	Start string `codec:"start" json:"start"`
+	// Path is the 32-bit FNV-1a hash of the path, written as the node types from one leaf up to the
+	// top node and down to the other leaf, i.e. Ident^AssignStmt_CallExpr_Ident.
+	Path uint32 `codec:"path" json:"path"`
+	End  string `codec:"end" json:"end"`
+}
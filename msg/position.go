@@ -0,0 +1,8 @@
+package msg
+
+// Position is a resolved location in the content of a request.
+type Position struct {
+	Offset int `codec:"offset" json:"offset"`
+	Line   int `codec:"line" json:"line"`
+	Column int `codec:"column" json:"column"`
+}
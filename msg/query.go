@@ -0,0 +1,13 @@
+package msg
+
+// QueryMatch is a node of the AST selected by a Query request.
+type QueryMatch struct {
+	// Node is the AST node type, i.e. CallExpr.
+	Node string `codec:"node" json:"node"`
+	// Field is the field of the parent node which holds the node, i.e. Fun. It is empty for the root.
+	Field string   `codec:"field,omitempty" json:"field,omitempty"`
+	Start Position `codec:"start" json:"start"`
+	End   Position `codec:"end" json:"end"`
+	// Source is the source of the node. It is only replied if Request.Source is set.
+	Source string `codec:"source,omitempty" json:"source,omitempty"`
+}
@@ -0,0 +1,15 @@
+package msg
+
+// Renamed is the result of a Rename request.
+type Renamed struct {
+	// Source is the content with every reference renamed.
+	Source string      `codec:"source" json:"source"`
+	Edits  []*TextEdit `codec:"edits" json:"edits"`
+}
+
+// TextEdit replaces the content between two positions with a new text.
+type TextEdit struct {
+	Start   Position `codec:"start" json:"start"`
+	End     Position `codec:"end" json:"end"`
+	NewText string   `codec:"new_text" json:"new_text"`
+}
@@ -0,0 +1,12 @@
+package msg
+
+import "go/ast"
+
+// Snippet is the AST of a fragment parsed with the ModeExpr, ModeStmts or ModeDecls modes. Only the
+// field matching the mode is set. Positions are relative to the fragment, as if it was a whole file.
+type Snippet struct {
+	Expr     ast.Expr            `codec:"expr,omitempty" json:"expr,omitempty"`
+	Stmts    []ast.Stmt          `codec:"stmts,omitempty" json:"stmts,omitempty"`
+	Decls    []ast.Decl          `codec:"decls,omitempty" json:"decls,omitempty"`
+	Comments []*ast.CommentGroup `codec:"comments,omitempty" json:"comments,omitempty"`
+}
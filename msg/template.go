@@ -0,0 +1,31 @@
+package msg
+
+// Template is a template parsed by a ParseTemplate request: the main one or one created with
+// define or block.
+type Template struct {
+	Name string        `codec:"name" json:"name"`
+	Root *TemplateNode `codec:"root" json:"root"`
+}
+
+// TemplateNode is a node of a template parse tree.
+type TemplateNode struct {
+	// Type is the node type: list, text, comment, action, pipeline, command, field, variable, chain,
+	// identifier, dot, nil, bool, number, string, if, range, with, template, break or continue.
+	Type  string   `codec:"type" json:"type"`
+	Start Position `codec:"start" json:"start"`
+	// Value is the text of text, comment, bool, number and string nodes, the name of identifiers and
+	// templates, and the assignment operator of pipelines which declare variables.
+	Value string `codec:"value,omitempty" json:"value,omitempty"`
+	// Ident are the identifiers of field, variable and chain nodes, i.e. ["$x", "Field"].
+	Ident []string `codec:"ident,omitempty" json:"ident,omitempty"`
+	// Decl are the variables declared by a pipeline.
+	Decl []*TemplateNode `codec:"decl,omitempty" json:"decl,omitempty"`
+	// Pipe is the pipeline of action, if, range, with and template nodes.
+	Pipe *TemplateNode `codec:"pipe,omitempty" json:"pipe,omitempty"`
+	// List and ElseList are the bodies of if, range and with nodes.
+	List     *TemplateNode `codec:"list,omitempty" json:"list,omitempty"`
+	ElseList *TemplateNode `codec:"else_list,omitempty" json:"else_list,omitempty"`
+	// Nodes are the children of list nodes, the commands of pipelines, the arguments of commands and
+	// the operand of chain nodes.
+	Nodes []*TemplateNode `codec:"nodes,omitempty" json:"nodes,omitempty"`
+}
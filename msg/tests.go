@@ -0,0 +1,25 @@
+package msg
+
+// TestFunc is a test, benchmark, fuzz test or example function found by a ListTests request.
+type TestFunc struct {
+	Name string `codec:"name" json:"name"`
+	// Kind is the function kind: test, benchmark, fuzz or example.
+	Kind  string   `codec:"kind" json:"kind"`
+	Start Position `codec:"start" json:"start"`
+	End   Position `codec:"end" json:"end"`
+	// Subtests are the subtests run with a literal name.
+	Subtests []*Subtest `codec:"subtests,omitempty" json:"subtests,omitempty"`
+	// Output is the expected output of an example. It is nil if the example has no output comment.
+	Output *string `codec:"output,omitempty" json:"output,omitempty"`
+	// Unordered reports whether the example output was declared as "Unordered output:".
+	Unordered bool `codec:"unordered,omitempty" json:"unordered,omitempty"`
+}
+
+// Subtest is a subtest or sub-benchmark started by a Run call with a literal name.
+type Subtest struct {
+	Name string `codec:"name" json:"name"`
+	// FullName is the name the go test -run flag matches, i.e. TestFoo/with_spaces.
+	FullName string     `codec:"full_name" json:"full_name"`
+	Start    Position   `codec:"start" json:"start"`
+	Subtests []*Subtest `codec:"subtests,omitempty" json:"subtests,omitempty"`
+}
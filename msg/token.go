@@ -0,0 +1,11 @@
+package msg
+
+// Token is a lexical token of the content.
+type Token struct {
+	// Kind is the token kind as go/token prints it, i.e. IDENT, INT, func, ( or ;.
+	Kind    string   `codec:"kind" json:"kind"`
+	Literal string   `codec:"literal,omitempty" json:"literal,omitempty"`
+	Start   Position `codec:"start" json:"start"`
+	// Inserted reports whether it is a semicolon inserted automatically at a newline or at EOF.
+	Inserted bool `codec:"inserted,omitempty" json:"inserted,omitempty"`
+}
@@ -0,0 +1,53 @@
+package msg
+
+// TypeInfo is the result of a TypeCheck request.
+type TypeInfo struct {
+	// Package is the name of the type-checked package.
+	Package string `codec:"package" json:"package"`
+	// Types records the type, and the constant value if any, of every expression.
+	Types []*TypedNode `codec:"types,omitempty" json:"types,omitempty"`
+	// Defs records the object defined by every identifier in a declaration.
+	Defs []*Object `codec:"defs,omitempty" json:"defs,omitempty"`
+	// Uses records the object referred by every identifier which is not a declaration.
+	Uses []*Object `codec:"uses,omitempty" json:"uses,omitempty"`
+	// Selections records the resolution of every selector expression which is not a qualified identifier.
+	Selections []*Selection `codec:"selections,omitempty" json:"selections,omitempty"`
+}
+
+// TypedNode is an expression of the AST with its type.
+type TypedNode struct {
+	// Node is the AST node type, i.e. CallExpr.
+	Node  string   `codec:"node" json:"node"`
+	Start Position `codec:"start" json:"start"`
+	End   Position `codec:"end" json:"end"`
+	// Mode is the kind of the expression: type, value, variable, constant, builtin, void, nil or novalue.
+	Mode string `codec:"mode" json:"mode"`
+	Type string `codec:"type" json:"type"`
+	// Value is the constant value of the expression, if any.
+	Value string `codec:"value,omitempty" json:"value,omitempty"`
+}
+
+// Object is an identifier and the object it denotes.
+type Object struct {
+	Name  string   `codec:"name" json:"name"`
+	Start Position `codec:"start" json:"start"`
+	// Kind is the object kind: var, const, type, func, package, label, builtin or nil.
+	Kind string `codec:"kind" json:"kind"`
+	Type string `codec:"type,omitempty" json:"type,omitempty"`
+	// Package is the path of the package the object belongs to. It is empty for universe objects.
+	Package string `codec:"package,omitempty" json:"package,omitempty"`
+	// Decl is the position of the object declaration. It is nil if it isn't declared in the content.
+	Decl *Position `codec:"decl,omitempty" json:"decl,omitempty"`
+}
+
+// Selection is a resolved selector expression.
+type Selection struct {
+	Start Position `codec:"start" json:"start"`
+	End   Position `codec:"end" json:"end"`
+	// Kind is the selector kind: field, method or methodexpr.
+	Kind string  `codec:"kind" json:"kind"`
+	Recv string  `codec:"recv" json:"recv"`
+	Obj  *Object `codec:"obj" json:"obj"`
+	// Indirect reports whether any pointer indirection was required to get from Recv to the object.
+	Indirect bool `codec:"indirect" json:"indirect"`
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// getNodeAt replies a msg.NodeAt request with the path of nodes enclosing m.Start, or the range
+// from m.Start to m.End, as astutil.PathEnclosingInterval computes it. A position out of the content
+// is replied with msg.Fatal status.
+func getNodeAt(m *msg.Request) *msg.Response {
+	res := newResponse()
+	if m.Start == nil {
+		res.Status = msg.Fatal
+		res.Errors = []string{"no start position in the request"}
+		return res
+	}
+
+	fset := token.NewFileSet()
+	tree, ok := parseFile(res, fset, m.Content)
+	if !ok {
+		return res
+	}
+
+	file := fset.File(tree.FileStart)
+	start, err := resolveOffset(file, m.Start)
+	if err != nil {
+		res.Status = msg.Fatal
+		res.Errors = []string{err.Error()}
+		return res
+	}
+
+	end := start
+	if m.End != nil {
+		if end, err = resolveOffset(file, m.End); err != nil {
+			res.Status = msg.Fatal
+			res.Errors = []string{err.Error()}
+			return res
+		}
+	}
+
+	if end < start {
+		start, end = end, start
+	}
+
+	path, exact := astutil.PathEnclosingInterval(tree, file.Pos(start), file.Pos(end))
+	nodePath := &msg.NodePath{Exact: exact}
+	for _, node := range path {
+		nodePath.Nodes = append(nodePath.Nodes, &msg.EnclosingNode{
+			Node:  nodeType(node),
+			Start: getPosition(fset, node.Pos()),
+			End:   getPosition(fset, node.End()),
+		})
+	}
+
+	res.NodePath = nodePath
+	return res
+}
+
+// resolveOffset returns the offset of a position in a file. The position is given by its line and
+// column if Line is set, or by its offset otherwise.
+func resolveOffset(file *token.File, pos *msg.Position) (int, error) {
+	offset := pos.Offset
+	if pos.Line > 0 {
+		if pos.Line > file.LineCount() || pos.Column < 1 {
+			return 0, fmt.Errorf("position %d:%d out of the content", pos.Line, pos.Column)
+		}
+
+		offset = file.Offset(file.LineStart(pos.Line)) + pos.Column - 1
+		if pos.Line < file.LineCount() && offset >= file.Offset(file.LineStart(pos.Line+1)) {
+			return 0, fmt.Errorf("position %d:%d out of the content", pos.Line, pos.Column)
+		}
+	}
+
+	if offset < 0 || offset > file.Size() {
+		return 0, fmt.Errorf("offset %d out of the content", offset)
+	}
+
+	return offset, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+const nodeAtSource = "package p\n\nfunc f() {\n\tg(x + 1)\n}\n"
+
+func TestGetNodeAt(t *testing.T) {
+	res := getResponse(&msg.Request{Action: msg.NodeAt, Content: nodeAtSource, Start: &msg.Position{Offset: 25}})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.True(t, res.NodePath.Exact)
+	require.Equal(t, []*msg.EnclosingNode{
+		{Node: "Ident", Start: msg.Position{Offset: 25, Line: 4, Column: 4}, End: msg.Position{Offset: 26, Line: 4, Column: 5}},
+		{Node: "BinaryExpr", Start: msg.Position{Offset: 25, Line: 4, Column: 4}, End: msg.Position{Offset: 30, Line: 4, Column: 9}},
+		{Node: "CallExpr", Start: msg.Position{Offset: 23, Line: 4, Column: 2}, End: msg.Position{Offset: 31, Line: 4, Column: 10}},
+		{Node: "ExprStmt", Start: msg.Position{Offset: 23, Line: 4, Column: 2}, End: msg.Position{Offset: 31, Line: 4, Column: 10}},
+		{Node: "BlockStmt", Start: msg.Position{Offset: 20, Line: 3, Column: 10}, End: msg.Position{Offset: 33, Line: 5, Column: 2}},
+		{Node: "FuncDecl", Start: msg.Position{Offset: 11, Line: 3, Column: 1}, End: msg.Position{Offset: 33, Line: 5, Column: 2}},
+		{Node: "File", Start: msg.Position{Offset: 0, Line: 1, Column: 1}, End: msg.Position{Offset: 33, Line: 5, Column: 2}},
+	}, res.NodePath.Nodes)
+
+	// the same position by line and column
+	res2 := getResponse(&msg.Request{Action: msg.NodeAt, Content: nodeAtSource, Start: &msg.Position{Line: 4, Column: 4}})
+	require.Equal(t, res.NodePath, res2.NodePath)
+
+	// a range covering the operands selects the binary expression
+	res = getResponse(&msg.Request{
+		Action:  msg.NodeAt,
+		Content: nodeAtSource,
+		Start:   &msg.Position{Offset: 25},
+		End:     &msg.Position{Line: 4, Column: 9},
+	})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.True(t, res.NodePath.Exact)
+	require.Equal(t, "BinaryExpr", res.NodePath.Nodes[0].Node)
+	require.Len(t, res.NodePath.Nodes, 6)
+}
+
+func TestGetNodeAtErrors(t *testing.T) {
+	tests := []struct {
+		start *msg.Position
+		err   string
+	}{
+		{nil, "no start position in the request"},
+		{&msg.Position{Offset: 100}, "offset 100 out of the content"},
+		{&msg.Position{Line: 9, Column: 1}, "position 9:1 out of the content"},
+		{&msg.Position{Line: 1, Column: 11}, "position 1:11 out of the content"},
+	}
+
+	for _, test := range tests {
+		res := getResponse(&msg.Request{Action: msg.NodeAt, Content: nodeAtSource, Start: test.start})
+		require.Equal(t, msg.Fatal, res.Status)
+		require.Equal(t, []string{test.err}, res.Errors)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+)
+
+// getOutline replies a msg.Outline request with the package name, the imports and the top-level
+// declarations of the content. Files with syntax errors are outlined as far as they could be parsed.
+func getOutline(m *msg.Request) *msg.Response {
+	res := newResponse()
+	fset := token.NewFileSet()
+	tree, ok := parseFile(res, fset, m.Content)
+	if !ok {
+		return res
+	}
+
+	res.Outline = getFileOutline(fset, tree)
+	return res
+}
+
+// getFileOutline builds the outline of a parsed file.
+func getFileOutline(fset *token.FileSet, tree *ast.File) *msg.FileOutline {
+	outline := &msg.FileOutline{Package: tree.Name.Name}
+	for _, spec := range tree.Imports {
+		imp := &msg.Import{
+			Start: getPosition(fset, spec.Pos()),
+			End:   getPosition(fset, spec.End()),
+		}
+
+		imp.Path, _ = strconv.Unquote(spec.Path.Value)
+		if spec.Name != nil {
+			imp.Name = spec.Name.Name
+		}
+
+		outline.Imports = append(outline.Imports, imp)
+	}
+
+	for _, decl := range tree.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			outline.Symbols = append(outline.Symbols, getFuncSymbol(fset, d))
+		case *ast.GenDecl:
+			outline.Symbols = append(outline.Symbols, getGenSymbols(fset, d)...)
+		}
+	}
+
+	return outline
+}
+
+// getFuncSymbol builds the msg.Symbol of a function or method declaration.
+func getFuncSymbol(fset *token.FileSet, decl *ast.FuncDecl) *msg.Symbol {
+	symbol := &msg.Symbol{
+		Name:      decl.Name.Name,
+		Kind:      "func",
+		Signature: render(fset, &ast.FuncDecl{Recv: decl.Recv, Name: decl.Name, Type: decl.Type}),
+		Exported:  decl.Name.IsExported(),
+		Doc:       decl.Doc.Text(),
+		Start:     getPosition(fset, decl.Pos()),
+		End:       getPosition(fset, decl.End()),
+	}
+
+	if decl.Recv != nil && len(decl.Recv.List) > 0 {
+		symbol.Kind = "method"
+		symbol.Recv = render(fset, decl.Recv.List[0].Type)
+	}
+
+	return symbol
+}
+
+// getGenSymbols builds the msg.Symbol of every type, const and var declared in a general
+// declaration. Imports are skipped. Ungrouped declarations take the position and the doc comment of
+// the whole declaration.
+func getGenSymbols(fset *token.FileSet, decl *ast.GenDecl) []*msg.Symbol {
+	if decl.Tok == token.IMPORT {
+		return nil
+	}
+
+	kind := strings.ToLower(decl.Tok.String())
+	grouped := decl.Lparen.IsValid()
+	var symbols []*msg.Symbol
+	for _, spec := range decl.Specs {
+		var node ast.Node = spec
+		doc := decl.Doc
+		if grouped {
+			doc = nil
+		} else {
+			node = decl
+		}
+
+		switch s := spec.(type) {
+		case *ast.TypeSpec:
+			if s.Doc != nil {
+				doc = s.Doc
+			}
+
+			symbols = append(symbols, &msg.Symbol{
+				Name:      s.Name.Name,
+				Kind:      kind,
+				Signature: "type " + render(fset, &ast.TypeSpec{Name: s.Name, TypeParams: s.TypeParams, Assign: s.Assign, Type: s.Type}),
+				Exported:  s.Name.IsExported(),
+				Doc:       doc.Text(),
+				Start:     getPosition(fset, node.Pos()),
+				End:       getPosition(fset, node.End()),
+			})
+		case *ast.ValueSpec:
+			if s.Doc != nil {
+				doc = s.Doc
+			}
+
+			signature := kind + " " + render(fset, &ast.ValueSpec{Names: s.Names, Type: s.Type, Values: s.Values})
+			for _, id := range s.Names {
+				if id.Name == "_" {
+					continue
+				}
+
+				symbols = append(symbols, &msg.Symbol{
+					Name:      id.Name,
+					Kind:      kind,
+					Signature: signature,
+					Exported:  id.IsExported(),
+					Doc:       doc.Text(),
+					Start:     getPosition(fset, node.Pos()),
+					End:       getPosition(fset, node.End()),
+				})
+			}
+		}
+	}
+
+	return symbols
+}
+
+// render prints a node as source. It returns an empty string if the node can't be printed.
+func render(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOutline(t *testing.T) {
+	source := `package foo
+
+import (
+	"fmt"
+	str "strings"
+)
+
+// Answer is the answer.
+const Answer = 42
+
+var (
+	// verbose enables the logs.
+	verbose, debug bool
+)
+
+// T is a type.
+type T struct{ name string }
+
+// Name returns the name.
+func (t *T) Name() string {
+	return str.ToUpper(t.name)
+}
+
+func print(a ...interface{}) { fmt.Println(a...) }
+`
+	res := getResponse(&msg.Request{Action: msg.Outline, Content: source})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Nil(t, res.AST)
+
+	outline := res.Outline
+	require.Equal(t, "foo", outline.Package)
+	require.Equal(t, []*msg.Import{
+		{Path: "fmt", Start: msg.Position{Offset: 23, Line: 4, Column: 2}, End: msg.Position{Offset: 28, Line: 4, Column: 7}},
+		{Name: "str", Path: "strings", Start: msg.Position{Offset: 30, Line: 5, Column: 2}, End: msg.Position{Offset: 43, Line: 5, Column: 15}},
+	}, outline.Imports)
+
+	require.Len(t, outline.Symbols, 6)
+	answer := outline.Symbols[0]
+	require.Equal(t, "Answer", answer.Name)
+	require.Equal(t, "const", answer.Kind)
+	require.Equal(t, "const Answer = 42", answer.Signature)
+	require.Equal(t, "Answer is the answer.\n", answer.Doc)
+	require.True(t, answer.Exported)
+	require.Equal(t, 9, answer.Start.Line)
+
+	for i, name := range []string{"verbose", "debug"} {
+		v := outline.Symbols[1+i]
+		require.Equal(t, name, v.Name)
+		require.Equal(t, "var", v.Kind)
+		require.Equal(t, "var verbose, debug bool", v.Signature)
+		require.Equal(t, "verbose enables the logs.\n", v.Doc)
+		require.False(t, v.Exported)
+		require.Equal(t, 13, v.Start.Line)
+	}
+
+	typ := outline.Symbols[3]
+	require.Equal(t, "type", typ.Kind)
+	require.Equal(t, "type T struct{ name string }", typ.Signature)
+	require.Equal(t, "T is a type.\n", typ.Doc)
+
+	method := outline.Symbols[4]
+	require.Equal(t, &msg.Symbol{
+		Name:      "Name",
+		Kind:      "method",
+		Recv:      "*T",
+		Signature: "func (t *T) Name() string",
+		Exported:  true,
+		Doc:       "Name returns the name.\n",
+		Start:     msg.Position{Offset: 223, Line: 20, Column: 1},
+		End:       msg.Position{Offset: 280, Line: 22, Column: 2},
+	}, method)
+
+	fn := outline.Symbols[5]
+	require.Equal(t, "print", fn.Name)
+	require.Equal(t, "func", fn.Kind)
+	require.Equal(t, "func print(a ...interface{})", fn.Signature)
+	require.Empty(t, fn.Doc)
+}
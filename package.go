@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+)
+
+// getParsePackage replies a msg.ParsePackage request with the ASTs of every file in m.Files. The
+// status and errors of each file are replied in its msg.ParsedFile; package-level diagnostics, like
+// mismatched package clauses or redeclarations, are replied in the response errors.
+func getParsePackage(m *msg.Request) *msg.Response {
+	res := newResponse()
+	if len(m.Files) == 0 {
+		res.Status = msg.Fatal
+		res.Errors = []string{"no files in the request"}
+		return res
+	}
+
+	fset := token.NewFileSet()
+	res.Package = parsePackage(fset, m.Files)
+	res.Status = msg.Ok
+	res.Errors = checkPackage(fset, res.Package)
+	for _, f := range res.Package.Files {
+		if f.Status != msg.Ok || len(res.Errors) > 0 {
+			res.Status = msg.Error
+		}
+
+		if f.AST != nil {
+			ast.Inspect(f.AST, setObjNil)
+		}
+	}
+
+	return res
+}
+
+// parsePackage parses every file in the same file set and collects the package-level declarations.
+func parsePackage(fset *token.FileSet, files []*msg.SourceFile) *msg.Package {
+	pkg := &msg.Package{}
+	for _, file := range files {
+		tree, status, errors := parse(fset, file.Name, file.Content)
+		parsed := &msg.ParsedFile{
+			Name:   file.Name,
+			Status: status,
+			Errors: errors,
+			AST:    tree,
+		}
+
+		parsed.Generator, parsed.Generated = generator(file.Content)
+		pkg.Files = append(pkg.Files, parsed)
+	}
+
+	pkg.Name = packageName(pkg.Files)
+	for _, f := range pkg.Files {
+		// files of other packages, like external tests, don't share the scope
+		if f.AST != nil && f.AST.Name.Name == pkg.Name {
+			pkg.Scope = append(pkg.Scope, getDeclarations(fset, f.Name, f.AST)...)
+		}
+	}
+
+	return pkg
+}
+
+// packageName returns the name of the package of some files: the first package clause which isn't
+// of an external test package, or the name of the package tested by the first one if all are. Files
+// without a package clause, whose name the parser replaces by a blank identifier, are skipped.
+func packageName(files []*msg.ParsedFile) string {
+	var tested string
+	for _, f := range files {
+		if f.AST == nil || f.AST.Name.Name == "" || f.AST.Name.Name == "_" {
+			continue
+		}
+
+		name := f.AST.Name.Name
+		if !strings.HasSuffix(name, "_test") {
+			return name
+		}
+
+		if tested == "" {
+			tested = strings.TrimSuffix(name, "_test")
+		}
+	}
+
+	return tested
+}
+
+// getDeclarations returns the package-level declarations of a file. Methods, init functions and
+// blank identifiers don't belong to the package scope and are skipped.
+func getDeclarations(fset *token.FileSet, name string, tree *ast.File) []*msg.Declaration {
+	var decls []*msg.Declaration
+	add := func(id *ast.Ident, kind string) {
+		if id.Name == "_" {
+			return
+		}
+
+		decls = append(decls, &msg.Declaration{
+			Name:  id.Name,
+			Kind:  kind,
+			File:  name,
+			Start: getPosition(fset, id.Pos()),
+		})
+	}
+
+	for _, decl := range tree.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil && d.Name.Name != "init" {
+				add(d.Name, "func")
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					add(s.Name, "type")
+				case *ast.ValueSpec:
+					for _, id := range s.Names {
+						add(id, strings.ToLower(d.Tok.String()))
+					}
+				}
+			}
+		}
+	}
+
+	return decls
+}
+
+// checkPackage looks for package-level problems between files: duplicated file names, mismatched
+// package clauses and identifiers redeclared in several files.
+func checkPackage(fset *token.FileSet, pkg *msg.Package) []string {
+	var errors []string
+	names := make(map[string]bool)
+	for _, f := range pkg.Files {
+		if names[f.Name] {
+			errors = append(errors, fmt.Sprintf("%s: duplicated file name", f.Name))
+		}
+
+		names[f.Name] = true
+		if f.AST == nil || f.AST.Name.Name == pkg.Name {
+			continue
+		}
+
+		// external test packages can live with the package they test
+		if strings.HasSuffix(f.Name, "_test.go") && f.AST.Name.Name == pkg.Name+"_test" {
+			continue
+		}
+
+		errors = append(errors, fmt.Sprintf("%s: package %s; expected %s",
+			fset.Position(f.AST.Name.Pos()), f.AST.Name.Name, pkg.Name))
+	}
+
+	declared := make(map[string]*msg.Declaration)
+	for _, decl := range pkg.Scope {
+		prev, ok := declared[decl.Name]
+		if !ok {
+			declared[decl.Name] = decl
+			continue
+		}
+
+		errors = append(errors, fmt.Sprintf("%s:%d:%d: %s redeclared in this block (other declaration at %s:%d:%d)",
+			decl.File, decl.Start.Line, decl.Start.Column, decl.Name,
+			prev.File, prev.Start.Line, prev.Start.Column))
+	}
+
+	return errors
+}
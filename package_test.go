@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetParsePackage(t *testing.T) {
+	req := &msg.Request{
+		Action: msg.ParsePackage,
+		Files: []*msg.SourceFile{
+			{Name: "a.go", Content: "package foo\n\nconst A = 1\n\nfunc (t T) Method() {}\n"},
+			{Name: "b.go", Content: "package foo\n\ntype T int\n\nvar x, _ = 1, 2\n"},
+			{Name: "b_test.go", Content: "package foo_test\n\nconst A = 2\n"},
+		},
+	}
+
+	res := getResponse(req)
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Nil(t, res.AST)
+	require.Equal(t, "foo", res.Package.Name)
+	require.Len(t, res.Package.Files, 3)
+	for i, f := range res.Package.Files {
+		require.Equal(t, req.Files[i].Name, f.Name)
+		require.Equal(t, msg.Ok, f.Status)
+		require.NotNil(t, f.AST)
+	}
+
+	// positions are shared between files
+	require.True(t, res.Package.Files[0].AST.End() < res.Package.Files[1].AST.Pos())
+
+	require.Equal(t, []*msg.Declaration{
+		{Name: "A", Kind: "const", File: "a.go", Start: msg.Position{Offset: 19, Line: 3, Column: 7}},
+		{Name: "T", Kind: "type", File: "b.go", Start: msg.Position{Offset: 18, Line: 3, Column: 6}},
+		{Name: "x", Kind: "var", File: "b.go", Start: msg.Position{Offset: 29, Line: 5, Column: 5}},
+	}, res.Package.Scope)
+}
+
+func TestGetParsePackageErrors(t *testing.T) {
+	req := &msg.Request{
+		Action: msg.ParsePackage,
+		Files: []*msg.SourceFile{
+			{Name: "a.go", Content: "package foo\n\nfunc F() {}\n"},
+			{Name: "b.go", Content: "package bar\n"},
+			{Name: "c.go", Content: "package foo\n\nvar F = 1\n"},
+			{Name: "d.go", Content: "package foo\n\nfunc {\n"},
+		},
+	}
+
+	res := getResponse(req)
+	require.Equal(t, msg.Error, res.Status)
+	require.Equal(t, []string{
+		"b.go:1:9: package bar; expected foo",
+		"c.go:3:5: F redeclared in this block (other declaration at a.go:3:6)",
+	}, res.Errors)
+	require.Equal(t, msg.Error, res.Package.Files[3].Status)
+	require.NotEmpty(t, res.Package.Files[3].Errors)
+}
+
+func TestGetParsePackageFileOrder(t *testing.T) {
+	res := getResponse(&msg.Request{
+		Action: msg.ParsePackage,
+		Files: []*msg.SourceFile{
+			{Name: "broken.go", Content: "func F() {}\n"},
+			{Name: "a_test.go", Content: "package foo_test\n\nvar T = 1\n"},
+			{Name: "a.go", Content: "package foo\n\nvar A = 1\n"},
+			{Name: "b.go", Content: "package foo\n\nvar B = 1\n"},
+		},
+	})
+	require.Equal(t, msg.Error, res.Status)
+	require.Equal(t, "foo", res.Package.Name)
+	require.Len(t, res.Package.Scope, 2)
+	for _, err := range res.Errors {
+		require.NotContains(t, err, "a.go")
+		require.NotContains(t, err, "b.go")
+	}
+
+	res = getResponse(&msg.Request{
+		Action: msg.ParsePackage,
+		Files:  []*msg.SourceFile{{Name: "a_test.go", Content: "package foo_test\n"}},
+	})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Equal(t, "foo", res.Package.Name)
+}
+
+func TestGetParsePackageNoFiles(t *testing.T) {
+	res := getResponse(&msg.Request{Action: msg.ParsePackage})
+	require.Equal(t, msg.Fatal, res.Status)
+	require.Nil(t, res.Package)
+}
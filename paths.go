@@ -0,0 +1,210 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+)
+
+const (
+	defaultMaxPathLength = 8
+	defaultMaxPathWidth  = 2
+	defaultMaxContexts   = 200
+	// methodNameToken replaces the name of the function in its own contexts, as code2vec does, so
+	// the name can be used as the label to predict.
+	methodNameToken = "METHOD_NAME"
+)
+
+// pathNode is a node of the AST with its ancestors, used to compute the paths between leaves.
+type pathNode struct {
+	node   ast.Node
+	parent *pathNode
+	depth  int
+	// index is the position of the node between the children of its parent.
+	index    int
+	children int
+}
+
+// getPathContexts replies a msg.PathContexts request with the leaf-to-leaf path contexts of every
+// function and method declaration of the content. The leaves are the identifiers and the basic
+// literals.
+func getPathContexts(m *msg.Request) *msg.Response {
+	res := newResponse()
+	fset := token.NewFileSet()
+	tree, ok := parseFile(res, fset, m.Content)
+	if !ok {
+		return res
+	}
+
+	opts := msg.PathOptions{}
+	if m.Paths != nil {
+		opts = *m.Paths
+	}
+
+	if opts.MaxLength == 0 {
+		opts.MaxLength = defaultMaxPathLength
+	}
+
+	if opts.MaxWidth == 0 {
+		opts.MaxWidth = defaultMaxPathWidth
+	}
+
+	if opts.MaxContexts == 0 {
+		opts.MaxContexts = defaultMaxContexts
+	}
+
+	res.PathContexts = []*msg.FuncPaths{}
+	for _, decl := range tree.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+
+		paths := &msg.FuncPaths{
+			Name:     fn.Name.Name,
+			Start:    getPosition(fset, fn.Pos()),
+			Contexts: getFuncContexts(fn, opts),
+		}
+
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			paths.Recv = render(fset, fn.Recv.List[0].Type)
+		}
+
+		res.PathContexts = append(res.PathContexts, paths)
+	}
+
+	return res
+}
+
+// getFuncContexts returns the path contexts between every pair of leaves of a function which fit
+// in the options, sampled down to opts.MaxContexts.
+func getFuncContexts(fn *ast.FuncDecl, opts msg.PathOptions) []*msg.PathContext {
+	leaves := getLeaves(fn)
+	var contexts []*msg.PathContext
+	for i, start := range leaves {
+		for _, end := range leaves[i+1:] {
+			path, ok := getPath(start, end, opts)
+			if !ok {
+				continue
+			}
+
+			h := fnv.New32a()
+			h.Write([]byte(path))
+			contexts = append(contexts, &msg.PathContext{
+				Start: leafToken(fn, start.node),
+				Path:  h.Sum32(),
+				End:   leafToken(fn, end.node),
+			})
+		}
+	}
+
+	if opts.MaxContexts < 0 || len(contexts) <= opts.MaxContexts {
+		return contexts
+	}
+
+	// the sample keeps the source order of the contexts
+	picked := rand.New(rand.NewSource(opts.Seed)).Perm(len(contexts))[:opts.MaxContexts]
+	sort.Ints(picked)
+	sample := make([]*msg.PathContext, 0, len(picked))
+	for _, i := range picked {
+		sample = append(sample, contexts[i])
+	}
+
+	return sample
+}
+
+// getLeaves returns the identifiers and basic literals under a node, in source order, linked to
+// their ancestors up to the node.
+func getLeaves(root ast.Node) []*pathNode {
+	var leaves []*pathNode
+	var stack []*pathNode
+	ast.Inspect(root, func(node ast.Node) bool {
+		if node == nil {
+			stack = stack[:len(stack)-1]
+			return false
+		}
+
+		n := &pathNode{node: node}
+		if len(stack) > 0 {
+			n.parent = stack[len(stack)-1]
+			n.depth = n.parent.depth + 1
+			n.index = n.parent.children
+			n.parent.children++
+		}
+
+		switch node.(type) {
+		case *ast.Ident, *ast.BasicLit:
+			leaves = append(leaves, n)
+		}
+
+		stack = append(stack, n)
+		return true
+	})
+
+	return leaves
+}
+
+// getPath writes the path between two leaves, i.e. Ident^AssignStmt_CallExpr_Ident. It returns
+// false if the path is longer or wider than the options allow.
+func getPath(start, end *pathNode, opts msg.PathOptions) (string, bool) {
+	var up, down []*pathNode
+	a, b := start, end
+	for a.depth > b.depth {
+		up, a = append(up, a), a.parent
+	}
+
+	for b.depth > a.depth {
+		down, b = append(down, b), b.parent
+	}
+
+	for a.parent != b.parent {
+		up, a = append(up, a), a.parent
+		down, b = append(down, b), b.parent
+	}
+
+	// a and b are now the children of the top node the leaves descend from
+	up, down = append(up, a), append(down, b)
+	if len(up)+len(down) > opts.MaxLength {
+		return "", false
+	}
+
+	if width := b.index - a.index; width > opts.MaxWidth || -width > opts.MaxWidth {
+		return "", false
+	}
+
+	var path strings.Builder
+	for _, n := range up {
+		path.WriteString(nodeType(n.node))
+		path.WriteByte('^')
+	}
+
+	path.WriteString(nodeType(a.parent.node))
+	for i := len(down) - 1; i >= 0; i-- {
+		path.WriteByte('_')
+		path.WriteString(nodeType(down[i].node))
+	}
+
+	return path.String(), true
+}
+
+// leafToken returns the token of a leaf: the name of an identifier or the value of a literal. The
+// name of the function itself is replaced by methodNameToken.
+func leafToken(fn *ast.FuncDecl, node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.Ident:
+		if n == fn.Name {
+			return methodNameToken
+		}
+
+		return n.Name
+	case *ast.BasicLit:
+		return n.Value
+	}
+
+	return ""
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"hash/fnv"
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+const pathsSource = `package foo
+
+func f(x int) int {
+	return x + 1
+}
+
+func (t *T) Method() {}
+`
+
+// pathHash returns the hash a path is replied with: the 32-bit FNV-1a hash of its node types.
+func pathHash(path string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(path))
+	return h.Sum32()
+}
+
+func TestGetPathContexts(t *testing.T) {
+	res := getResponse(&msg.Request{
+		Action:  msg.PathContexts,
+		Content: pathsSource,
+		Paths:   &msg.PathOptions{MaxLength: 2},
+	})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Equal(t, []*msg.FuncPaths{
+		{
+			Name:  "f",
+			Start: msg.Position{Offset: 13, Line: 3, Column: 1},
+			Contexts: []*msg.PathContext{
+				{Start: "x", Path: pathHash("Ident^Field_Ident"), End: "int"},
+				{Start: "x", Path: pathHash("Ident^BinaryExpr_BasicLit"), End: "1"},
+			},
+		},
+		{
+			Name:  "Method",
+			Recv:  "*T",
+			Start: msg.Position{Offset: 50, Line: 7, Column: 1},
+		},
+	}, res.PathContexts)
+}
+
+func TestGetPathContextsOptions(t *testing.T) {
+	res := getResponse(&msg.Request{Action: msg.PathContexts, Content: pathsSource})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	contexts := res.PathContexts[0].Contexts
+	require.Contains(t, contexts, &msg.PathContext{
+		Start: methodNameToken,
+		Path:  pathHash("Ident^FuncDecl_BlockStmt_ReturnStmt_BinaryExpr_Ident"),
+		End:   "x",
+	})
+
+	require.Equal(t, []*msg.PathContext{
+		{Start: "t", Path: pathHash("Ident^Field_StarExpr_Ident"), End: "T"},
+		{Start: "t", Path: pathHash("Ident^Field^FieldList^FuncDecl_Ident"), End: methodNameToken},
+		{Start: "T", Path: pathHash("Ident^StarExpr^Field^FieldList^FuncDecl_Ident"), End: methodNameToken},
+	}, res.PathContexts[1].Contexts)
+
+	// the name and the body are two children apart
+	res = getResponse(&msg.Request{Action: msg.PathContexts, Content: pathsSource, Paths: &msg.PathOptions{MaxWidth: 1}})
+	require.NotContains(t, res.PathContexts[0].Contexts, &msg.PathContext{
+		Start: methodNameToken,
+		Path:  pathHash("Ident^FuncDecl_BlockStmt_ReturnStmt_BinaryExpr_Ident"),
+		End:   "x",
+	})
+
+	opts := &msg.PathOptions{MaxContexts: 3, Seed: 42}
+	res = getResponse(&msg.Request{Action: msg.PathContexts, Content: pathsSource, Paths: opts})
+	sample := res.PathContexts[0].Contexts
+	require.Len(t, sample, 3)
+	for _, c := range sample {
+		require.Contains(t, contexts, c)
+	}
+
+	res = getResponse(&msg.Request{Action: msg.PathContexts, Content: pathsSource, Paths: opts})
+	require.Equal(t, sample, res.PathContexts[0].Contexts)
+}
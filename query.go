@@ -0,0 +1,574 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+)
+
+// getQuery replies a msg.Query request with the nodes of the AST of the content selected by
+// m.Query, in source order. Queries are XPath-like paths of steps:
+//
+//	/File/Decls:FuncDecl          the steps separated by / select children and by // descendants
+//	//CallExpr[Fun:SelectorExpr]  a step is a node type or *, optionally after the parent field
+//	//FuncDecl[@Name="main"]      predicates compare the fields of the node with =, != or ~= (regexp)
+//	//CallExpr[@Fun.Sel.Name]     a field alone checks it isn't empty; fields are followed with dots
+//	//IfStmt[not(@Else) and //ReturnStmt]  paths check there is some node; and, or and not combine
+//
+// Identifiers compare by their name, tokens by their text and any other node by its type. A query
+// which can't be parsed is replied with msg.Fatal status.
+func getQuery(m *msg.Request) *msg.Response {
+	res := newResponse()
+	query, err := parseQuery(m.Query)
+	if err != nil {
+		res.Status = msg.Fatal
+		res.Errors = []string{err.Error()}
+		return res
+	}
+
+	fset := token.NewFileSet()
+	tree, ok := parseFile(res, fset, m.Content)
+	if !ok {
+		return res
+	}
+
+	order := make(map[ast.Node]int)
+	ast.Inspect(tree, func(node ast.Node) bool {
+		if node != nil {
+			order[node] = len(order)
+		}
+
+		return true
+	})
+
+	matches := query.eval([]*queryNode{{node: tree}}, true)
+	sort.SliceStable(matches, func(i, j int) bool { return order[matches[i].node] < order[matches[j].node] })
+
+	res.Matches = []*msg.QueryMatch{}
+	for _, n := range matches {
+		match := &msg.QueryMatch{
+			Node:  nodeType(n.node),
+			Field: n.field,
+			Start: getPosition(fset, n.node.Pos()),
+			End:   getPosition(fset, n.node.End()),
+		}
+
+		// nodes of contents with syntax errors may have no valid end
+		if m.Source && n.node.Pos().IsValid() && n.node.End().IsValid() && match.Start.Offset <= match.End.Offset {
+			match.Source = m.Content[match.Start.Offset:match.End.Offset]
+		}
+
+		res.Matches = append(res.Matches, match)
+	}
+
+	return res
+}
+
+// queryNode is a node of the AST and the field of its parent which holds it.
+type queryNode struct {
+	node  ast.Node
+	field string
+}
+
+// queryChildren returns the AST nodes held by the fields of a node. As in ast.Inspect, the imports,
+// unresolved identifiers and comments of a file are only reached from their declarations.
+func queryChildren(n ast.Node) []*queryNode {
+	var children []*queryNode
+	_, isFile := n.(*ast.File)
+	v := reflect.ValueOf(n).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		if isFile && (name == "Imports" || name == "Unresolved" || name == "Comments") {
+			continue
+		}
+
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			if child, ok := f.Interface().(ast.Node); ok && !f.IsNil() {
+				children = append(children, &queryNode{node: child, field: name})
+			}
+		case reflect.Slice:
+			for j := 0; j < f.Len(); j++ {
+				if child, ok := f.Index(j).Interface().(ast.Node); ok && !f.Index(j).IsNil() {
+					children = append(children, &queryNode{node: child, field: name})
+				}
+			}
+		}
+	}
+
+	return children
+}
+
+// queryDescendants returns the descendants of a node in pre-order.
+func queryDescendants(n ast.Node) []*queryNode {
+	var list []*queryNode
+	for _, c := range queryChildren(n) {
+		list = append(list, c)
+		list = append(list, queryDescendants(c.node)...)
+	}
+
+	return list
+}
+
+// queryPath is a list of steps.
+type queryPath []*queryStep
+
+// queryStep selects the children, or the descendants, of a type and held by a field which satisfy
+// every predicate.
+type queryStep struct {
+	descendant bool
+	field      string
+	typ        string
+	preds      []queryExpr
+}
+
+// eval returns the nodes selected by the path from a list of nodes. An absolute path starts from
+// the root, so its first step may select the root itself.
+func (p queryPath) eval(nodes []*queryNode, absolute bool) []*queryNode {
+	for i, step := range p {
+		seen := make(map[ast.Node]bool)
+		var next []*queryNode
+		for _, n := range nodes {
+			var candidates []*queryNode
+			switch {
+			case i == 0 && absolute && step.descendant:
+				candidates = append([]*queryNode{n}, queryDescendants(n.node)...)
+			case i == 0 && absolute:
+				candidates = []*queryNode{n}
+			case step.descendant:
+				candidates = queryDescendants(n.node)
+			default:
+				candidates = queryChildren(n.node)
+			}
+
+			for _, c := range candidates {
+				if !seen[c.node] && step.match(c) {
+					seen[c.node] = true
+					next = append(next, c)
+				}
+			}
+		}
+
+		nodes = next
+	}
+
+	return nodes
+}
+
+// match reports whether a node satisfies the type, the field and the predicates of the step.
+func (s *queryStep) match(n *queryNode) bool {
+	if s.typ != "*" && s.typ != nodeType(n.node) {
+		return false
+	}
+
+	if s.field != "" && s.field != n.field {
+		return false
+	}
+
+	for _, pred := range s.preds {
+		if !pred.eval(n) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// queryExpr is a predicate of a step.
+type queryExpr interface {
+	eval(n *queryNode) bool
+}
+
+// queryAnd is satisfied if both predicates are.
+type queryAnd struct{ x, y queryExpr }
+
+func (e *queryAnd) eval(n *queryNode) bool { return e.x.eval(n) && e.y.eval(n) }
+
+// queryOr is satisfied if any predicate is.
+type queryOr struct{ x, y queryExpr }
+
+func (e *queryOr) eval(n *queryNode) bool { return e.x.eval(n) || e.y.eval(n) }
+
+// queryNot is satisfied if its predicate isn't.
+type queryNot struct{ x queryExpr }
+
+func (e *queryNot) eval(n *queryNode) bool { return !e.x.eval(n) }
+
+// queryExists is satisfied if a relative path selects some node.
+type queryExists struct{ path queryPath }
+
+func (e *queryExists) eval(n *queryNode) bool { return len(e.path.eval([]*queryNode{n}, false)) > 0 }
+
+// queryAttr compares a field of the node, or checks it isn't empty if there is no operator.
+type queryAttr struct {
+	fields []string
+	op     string
+	value  string
+	re     *regexp.Regexp
+}
+
+func (e *queryAttr) eval(n *queryNode) bool {
+	value, ok := queryValue(n.node, e.fields)
+	switch e.op {
+	case "=":
+		return ok && value == e.value
+	case "!=":
+		return !ok || value != e.value
+	case "~=":
+		return ok && e.re.MatchString(value)
+	}
+
+	return ok && value != "" && value != "0" && value != "false"
+}
+
+// queryValue follows a list of fields from a node and returns the text of the last one. It returns
+// false if a field doesn't exist or is nil.
+func queryValue(node ast.Node, fields []string) (string, bool) {
+	v := reflect.ValueOf(node)
+	for _, name := range fields {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return "", false
+			}
+
+			v = v.Elem()
+		}
+
+		if v.Kind() != reflect.Struct {
+			return "", false
+		}
+
+		if v = v.FieldByName(name); !v.IsValid() {
+			return "", false
+		}
+	}
+
+	if !v.CanInterface() || (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return "", false
+	}
+
+	switch x := v.Interface().(type) {
+	case *ast.Ident:
+		return x.Name, true
+	case ast.Node:
+		return nodeType(x), true
+	case fmt.Stringer:
+		return x.String(), true
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Slice:
+		return strconv.Itoa(v.Len()), true
+	}
+
+	return "", false
+}
+
+// queryParser is a recursive descent parser of queries.
+type queryParser struct {
+	src    string
+	tokens []queryToken
+	pos    int
+}
+
+// queryToken is a token of a query: an operator, a name or a quoted string.
+type queryToken struct {
+	text   string
+	offset int
+	quoted bool
+}
+
+// queryOperators are the operators of the query language, the longest first.
+var queryOperators = []string{"//", "!=", "~=", "/", "[", "]", "(", ")", ":", "@", ".", "=", "*"}
+
+// parseQuery parses a query. Errors are prefixed with the column of the query where they happen.
+func parseQuery(src string) (p queryPath, err error) {
+	qp := &queryParser{src: src}
+	defer func() {
+		if r := recover(); r != nil {
+			qerr, ok := r.(queryError)
+			if !ok {
+				panic(r)
+			}
+
+			p, err = nil, qerr
+		}
+	}()
+
+	qp.scan()
+	if qp.peek() != "/" && qp.peek() != "//" {
+		qp.fail("query must start with / or //")
+	}
+
+	p = qp.path()
+	if qp.pos < len(qp.tokens) {
+		qp.fail("unexpected %q", qp.peek())
+	}
+
+	return p, nil
+}
+
+// queryError is an error parsing a query.
+type queryError string
+
+func (e queryError) Error() string { return string(e) }
+
+// fail aborts the parsing with an error at the current token.
+func (qp *queryParser) fail(format string, args ...interface{}) {
+	offset := len(qp.src)
+	if qp.pos < len(qp.tokens) {
+		offset = qp.tokens[qp.pos].offset
+	}
+
+	panic(queryError(fmt.Sprintf("query:%d: %s", offset+1, fmt.Sprintf(format, args...))))
+}
+
+// scan splits the query in tokens.
+func (qp *queryParser) scan() {
+	src := qp.src
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(src) && src[end] != src[i] {
+				if src[end] == '\\' {
+					end++
+				}
+
+				end++
+			}
+
+			if end >= len(src) {
+				qp.pos = len(qp.tokens)
+				qp.tokens = append(qp.tokens, queryToken{offset: i})
+				qp.fail("unterminated string")
+			}
+
+			text := src[i+1 : end]
+			if c == '"' {
+				unquoted, err := strconv.Unquote(src[i : end+1])
+				if err != nil {
+					qp.pos = len(qp.tokens)
+					qp.tokens = append(qp.tokens, queryToken{offset: i})
+					qp.fail("invalid string: %v", err)
+				}
+
+				text = unquoted
+			}
+
+			qp.tokens = append(qp.tokens, queryToken{text: text, offset: i, quoted: true})
+			i = end + 1
+		case c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c) || c == '-' || c == '+':
+			end := i
+			for end < len(src) && (src[end] == '_' || src[end] == '-' || src[end] == '+' ||
+				unicode.IsLetter(rune(src[end])) || unicode.IsDigit(rune(src[end]))) {
+				end++
+			}
+
+			qp.tokens = append(qp.tokens, queryToken{text: src[i:end], offset: i})
+			i = end
+		default:
+			op := ""
+			for _, o := range queryOperators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+
+			if op == "" {
+				qp.pos = len(qp.tokens)
+				qp.tokens = append(qp.tokens, queryToken{offset: i})
+				qp.fail("unexpected character %q", c)
+			}
+
+			qp.tokens = append(qp.tokens, queryToken{text: op, offset: i})
+			i += len(op)
+		}
+	}
+}
+
+// peek returns the text of the current token, or an empty string at the end. Quoted strings are
+// never returned as operators.
+func (qp *queryParser) peek() string {
+	if qp.pos >= len(qp.tokens) || qp.tokens[qp.pos].quoted {
+		return ""
+	}
+
+	return qp.tokens[qp.pos].text
+}
+
+// expect consumes the current token, which must be text.
+func (qp *queryParser) expect(text string) {
+	if qp.peek() != text {
+		qp.fail("expected %q", text)
+	}
+
+	qp.pos++
+}
+
+// name consumes a name: a node type, a field or a keyword.
+func (qp *queryParser) name() string {
+	if qp.pos >= len(qp.tokens) || qp.tokens[qp.pos].quoted || !isQueryName(qp.tokens[qp.pos].text) {
+		qp.fail("expected a name")
+	}
+
+	qp.pos++
+	return qp.tokens[qp.pos-1].text
+}
+
+// isQueryName reports whether a token is a name.
+func isQueryName(text string) bool {
+	for i, r := range text {
+		if !(r == '_' || unicode.IsLetter(r) || i > 0 && unicode.IsDigit(r)) {
+			return false
+		}
+	}
+
+	return text != ""
+}
+
+// path parses steps separated by / or //. The first step of a path inside a predicate selects
+// children if there is no leading slash.
+func (qp *queryParser) path() queryPath {
+	var p queryPath
+	for {
+		step := &queryStep{}
+		switch qp.peek() {
+		case "//":
+			step.descendant = true
+			qp.pos++
+		case "/":
+			qp.pos++
+		default:
+			if len(p) > 0 {
+				return p
+			}
+		}
+
+		qp.step(step)
+		p = append(p, step)
+	}
+}
+
+// step parses a step: [field:](type|*) followed by its predicates.
+func (qp *queryParser) step(step *queryStep) {
+	if qp.peek() == "*" {
+		qp.pos++
+		step.typ = "*"
+	} else {
+		step.typ = qp.name()
+		if qp.peek() == ":" {
+			qp.pos++
+			step.field = step.typ
+			if qp.peek() == "*" {
+				qp.pos++
+				step.typ = "*"
+			} else {
+				step.typ = qp.name()
+			}
+		}
+	}
+
+	for qp.peek() == "[" {
+		qp.pos++
+		step.preds = append(step.preds, qp.or())
+		qp.expect("]")
+	}
+}
+
+// or parses predicates joined by or.
+func (qp *queryParser) or() queryExpr {
+	x := qp.and()
+	for qp.peek() == "or" {
+		qp.pos++
+		x = &queryOr{x: x, y: qp.and()}
+	}
+
+	return x
+}
+
+// and parses predicates joined by and.
+func (qp *queryParser) and() queryExpr {
+	x := qp.factor()
+	for qp.peek() == "and" {
+		qp.pos++
+		x = &queryAnd{x: x, y: qp.factor()}
+	}
+
+	return x
+}
+
+// factor parses a negation, a parenthesized predicate, a field comparison or a relative path.
+func (qp *queryParser) factor() queryExpr {
+	switch qp.peek() {
+	case "not":
+		qp.pos++
+		return &queryNot{x: qp.factor()}
+	case "(":
+		qp.pos++
+		x := qp.or()
+		qp.expect(")")
+		return x
+	case "@":
+		qp.pos++
+		attr := &queryAttr{fields: []string{qp.name()}}
+		for qp.peek() == "." {
+			qp.pos++
+			attr.fields = append(attr.fields, qp.name())
+		}
+
+		switch op := qp.peek(); op {
+		case "=", "!=", "~=":
+			qp.pos++
+			if qp.pos >= len(qp.tokens) || qp.peek() != "" && !isQueryValue(qp.peek()) {
+				qp.fail("expected a value")
+			}
+
+			attr.op, attr.value = op, qp.tokens[qp.pos].text
+			if op == "~=" {
+				re, err := regexp.Compile(attr.value)
+				if err != nil {
+					qp.fail("invalid regexp: %v", err)
+				}
+
+				attr.re = re
+			}
+
+			qp.pos++
+		}
+
+		return attr
+	}
+
+	return &queryExists{path: qp.path()}
+}
+
+// isQueryValue reports whether an unquoted token can be a value: a name, a number or a sign.
+func isQueryValue(text string) bool {
+	for _, o := range queryOperators {
+		if text == o {
+			return false
+		}
+	}
+
+	return true
+}
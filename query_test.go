@@ -0,0 +1,104 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+const querySource = `package main
+
+import "database/sql"
+
+func main() {
+	db, _ := sql.Open("driver", "dsn")
+	db.Exec("DELETE")
+	if db != nil {
+		return
+	}
+
+	println(len("x"))
+}
+`
+
+func TestGetQuery(t *testing.T) {
+	res := getResponse(&msg.Request{
+		Action:  msg.Query,
+		Content: querySource,
+		Query:   `//CallExpr[Fun:SelectorExpr[@Sel.Name="Exec"]]`,
+		Source:  true,
+	})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Equal(t, []*msg.QueryMatch{
+		{
+			Node:   "CallExpr",
+			Field:  "X",
+			Start:  msg.Position{Offset: 88, Line: 7, Column: 2},
+			End:    msg.Position{Offset: 105, Line: 7, Column: 19},
+			Source: `db.Exec("DELETE")`,
+		},
+	}, res.Matches)
+}
+
+func TestGetQuerySelectors(t *testing.T) {
+	tests := []struct {
+		query   string
+		sources []string
+	}{
+		{`/File/Decls:FuncDecl/Name:Ident`, []string{"main"}},
+		{`//FuncDecl[@Name="main"]/Body:*/List:IfStmt/Cond:*`, []string{"db != nil"}},
+		{`//CallExpr[@Fun.Sel.Name ~= "^(Open|Exec)$"]/Args:BasicLit[@Kind=STRING][@Value!='"dsn"']`, []string{`"driver"`, `"DELETE"`}},
+		{`//CallExpr[Fun:Ident and //BasicLit]`, []string{`println(len("x"))`, `len("x")`}},
+		{`//IfStmt[not(@Else) and //ReturnStmt]/Body:BlockStmt/*`, []string{"return"}},
+		{`//BinaryExpr[@Op="!=" or @Op="=="]`, []string{"db != nil"}},
+		{`//AssignStmt[@Tok=":="]/Lhs:*`, []string{"db", "_"}},
+		{`//ImportSpec/*`, []string{`"database/sql"`}},
+	}
+
+	for _, test := range tests {
+		res := getResponse(&msg.Request{Action: msg.Query, Content: querySource, Query: test.query, Source: true})
+		require.Equal(t, msg.Ok, res.Status, "%s: %v", test.query, res.Errors)
+
+		var sources []string
+		for _, match := range res.Matches {
+			sources = append(sources, match.Source)
+		}
+
+		require.Equal(t, test.sources, sources, test.query)
+	}
+}
+
+func TestGetQueryErrors(t *testing.T) {
+	tests := map[string]string{
+		`CallExpr`:               "query:1: query must start with / or //",
+		`//CallExpr[`:            "query:12: expected a name",
+		`//CallExpr[@Name=]`:     "query:18: expected a value",
+		`//CallExpr[@Name="x]`:   "query:18: unterminated string",
+		`//CallExpr[@Name~="("]`: "query:19: invalid regexp: error parsing regexp: missing closing ): `(`",
+		`//CallExpr]`:            `query:11: unexpected "]"`,
+		`//Call$`:                `query:7: unexpected character '$'`,
+	}
+
+	for query, err := range tests {
+		res := getResponse(&msg.Request{Action: msg.Query, Content: querySource, Query: query})
+		require.Equal(t, msg.Fatal, res.Status, query)
+		require.Equal(t, []string{err}, res.Errors, query)
+	}
+}
+
+func TestGetQuerySourceSyntaxErrors(t *testing.T) {
+	for _, content := range []string{"package p\nfunc (", "package p\ntype T struct {"} {
+		res := getResponse(&msg.Request{Action: msg.Query, Content: content, Query: "//*", Source: true})
+		require.Equal(t, msg.Error, res.Status)
+		require.NotEmpty(t, res.Matches)
+		for _, match := range res.Matches {
+			if match.Start.Offset <= match.End.Offset {
+				require.Equal(t, content[match.Start.Offset:match.End.Offset], match.Source)
+			} else {
+				require.Empty(t, match.Source)
+			}
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// getFoldingRanges replies a msg.FoldingRanges request with the regions of m.Content which can be
+// folded: blocks, composite literals, field lists, call arguments and parenthesized declarations
+// fold between their delimiters, and comment groups as a whole. Regions within a line are left out.
+func getFoldingRanges(m *msg.Request) *msg.Response {
+	res := newResponse()
+	fset := token.NewFileSet()
+	tree, ok := parseFile(res, fset, m.Content)
+	if !ok {
+		return res
+	}
+
+	file := fset.File(tree.FileStart)
+	ranges := []*msg.FoldingRange{}
+	add := func(start, end token.Pos, kind string) {
+		if !start.IsValid() || !end.IsValid() || end <= start {
+			return
+		}
+
+		s := lspPosition(file, m.Content, file.Offset(start))
+		e := lspPosition(file, m.Content, file.Offset(end))
+		if s.Line >= e.Line {
+			return
+		}
+
+		ranges = append(ranges, &msg.FoldingRange{
+			StartLine:      s.Line,
+			StartCharacter: &s.Character,
+			EndLine:        e.Line,
+			EndCharacter:   &e.Character,
+			Kind:           kind,
+		})
+	}
+
+	ast.Inspect(tree, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.BlockStmt:
+			add(n.Lbrace+1, n.Rbrace, "")
+		case *ast.CaseClause:
+			add(n.Colon+1, n.End(), "")
+		case *ast.CommClause:
+			add(n.Colon+1, n.End(), "")
+		case *ast.CompositeLit:
+			add(n.Lbrace+1, n.Rbrace, "")
+		case *ast.CallExpr:
+			add(n.Lparen+1, n.Rparen, "")
+		case *ast.FieldList:
+			if n.Opening.IsValid() {
+				add(n.Opening+1, n.Closing, "")
+			}
+		case *ast.GenDecl:
+			if n.Lparen.IsValid() {
+				kind := ""
+				if n.Tok == token.IMPORT {
+					kind = "imports"
+				}
+
+				add(n.Lparen+1, n.Rparen, kind)
+			}
+		}
+
+		return true
+	})
+
+	for _, c := range tree.Comments {
+		add(c.Pos(), c.End(), "comment")
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		a, b := ranges[i], ranges[j]
+		if a.StartLine != b.StartLine {
+			return a.StartLine < b.StartLine
+		}
+
+		return *a.StartCharacter < *b.StartCharacter
+	})
+
+	res.FoldingRanges = ranges
+	return res
+}
+
+// getSelectionRanges replies a msg.SelectionRanges request with a range for every position of
+// m.Positions, whose parents are the ranges of the nodes enclosing it up to the whole content.
+// Nodes sharing a range, like an expression statement and its call, are reported once. A position
+// out of the content is replied with msg.Fatal status.
+func getSelectionRanges(m *msg.Request) *msg.Response {
+	res := newResponse()
+	if len(m.Positions) == 0 {
+		res.Status = msg.Fatal
+		res.Errors = []string{"no positions in the request"}
+		return res
+	}
+
+	fset := token.NewFileSet()
+	tree, ok := parseFile(res, fset, m.Content)
+	if !ok {
+		return res
+	}
+
+	file := fset.File(tree.FileStart)
+	offsets := make([]int, len(m.Positions))
+	for i, pos := range m.Positions {
+		offset, err := resolveOffset(file, pos)
+		if err != nil {
+			res.Status = msg.Fatal
+			res.Errors = []string{err.Error()}
+			return res
+		}
+
+		offsets[i] = offset
+	}
+
+	res.SelectionRanges = make([]*msg.SelectionRange, len(offsets))
+	for i, offset := range offsets {
+		pos := file.Pos(offset)
+		path, _ := astutil.PathEnclosingInterval(tree, pos, pos)
+
+		// the path goes from the innermost node to the file, so the chain is built outwards in
+		var outer *msg.SelectionRange
+		for j := len(path) - 1; j >= 0; j-- {
+			start, end := path[j].Pos(), path[j].End()
+			if _, ok := path[j].(*ast.File); ok {
+				start, end = tree.FileStart, tree.FileEnd
+			}
+
+			r := msg.LSPRange{
+				Start: lspPosition(file, m.Content, file.Offset(start)),
+				End:   lspPosition(file, m.Content, file.Offset(end)),
+			}
+
+			if outer != nil && outer.Range == r {
+				continue
+			}
+
+			outer = &msg.SelectionRange{Range: r, Parent: outer}
+		}
+
+		res.SelectionRanges[i] = outer
+	}
+
+	return res
+}
+
+// lspPosition returns the LSP position of an offset in the content of a file, with the character
+// counted in UTF-16 code units.
+func lspPosition(file *token.File, content string, offset int) msg.LSPPosition {
+	line := file.Line(file.Pos(offset))
+	// the file has no line for the end of content terminated by a newline
+	if offset == len(content) && offset > 0 && content[offset-1] == '\n' {
+		return msg.LSPPosition{Line: line}
+	}
+
+	lineStart := file.Offset(file.LineStart(line))
+	return msg.LSPPosition{Line: line - 1, Character: utf16Len(content[lineStart:offset])}
+}
+
+// utf16Len returns the length of a string in UTF-16 code units.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n++
+		if r >= 0x10000 {
+			n++
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+const rangesSource = `package foo
+
+import (
+	"fmt"
+	"os"
+)
+
+// F prints
+// the values.
+func F() {
+	x := []int{
+		1,
+	}
+	fmt.Println(x, os.Args)
+}
+`
+
+func TestGetFoldingRanges(t *testing.T) {
+	res := getResponse(&msg.Request{Action: msg.FoldingRanges, Content: rangesSource})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+
+	at := func(n int) *int { return &n }
+	require.Equal(t, []*msg.FoldingRange{
+		{StartLine: 2, StartCharacter: at(8), EndLine: 5, EndCharacter: at(0), Kind: "imports"},
+		{StartLine: 7, StartCharacter: at(0), EndLine: 8, EndCharacter: at(14), Kind: "comment"},
+		{StartLine: 9, StartCharacter: at(10), EndLine: 14, EndCharacter: at(0)},
+		{StartLine: 10, StartCharacter: at(12), EndLine: 12, EndCharacter: at(1)},
+	}, res.FoldingRanges)
+}
+
+func TestGetSelectionRanges(t *testing.T) {
+	res := getResponse(&msg.Request{
+		Action:    msg.SelectionRanges,
+		Content:   "package foo\n\nvar s = \"é😀\" + x\n",
+		Positions: []*msg.Position{{Line: 3, Column: 20}},
+	})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Len(t, res.SelectionRanges, 1)
+
+	var ranges []msg.LSPRange
+	for r := res.SelectionRanges[0]; r != nil; r = r.Parent {
+		ranges = append(ranges, r.Range)
+	}
+
+	pos := func(line, character int) msg.LSPPosition {
+		return msg.LSPPosition{Line: line, Character: character}
+	}
+
+	require.Equal(t, []msg.LSPRange{
+		{Start: pos(2, 16), End: pos(2, 17)},
+		{Start: pos(2, 8), End: pos(2, 17)},
+		{Start: pos(2, 4), End: pos(2, 17)},
+		{Start: pos(2, 0), End: pos(2, 17)},
+		{Start: pos(0, 0), End: pos(3, 0)},
+	}, ranges)
+}
+
+func TestGetSelectionRangesErrors(t *testing.T) {
+	res := getResponse(&msg.Request{Action: msg.SelectionRanges, Content: "package foo\n"})
+	require.Equal(t, msg.Fatal, res.Status)
+
+	res = getResponse(&msg.Request{
+		Action:    msg.SelectionRanges,
+		Content:   "package foo\n",
+		Positions: []*msg.Position{{Offset: 100}},
+	})
+	require.Equal(t, msg.Fatal, res.Status)
+	require.Equal(t, []string{"offset 100 out of the content"}, res.Errors)
+}
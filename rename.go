@@ -0,0 +1,339 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"strings"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+)
+
+// getRename replies a msg.Rename request with the content after renaming the identifier at m.Start
+// to m.NewName, along with every reference the parser resolves to the same object. Only objects
+// declared in the file can be renamed, so fields, including the keys of composite literals, methods
+// and imported names are refused, and references from other files of the package aren't renamed.
+// Names used as keys of composite literals whose type isn't declared in the file are refused too,
+// since there is no telling whether the keys are field names.
+// Renames which would redeclare a name or change the declaration any identifier refers to are
+// replied with msg.Fatal status. Contents with syntax errors are replied with their errors and
+// nothing renamed.
+func getRename(m *msg.Request) *msg.Response {
+	res := newResponse()
+	if m.Start == nil {
+		res.Status = msg.Fatal
+		res.Errors = []string{"no start position in the request"}
+		return res
+	}
+
+	if !token.IsIdentifier(m.NewName) || m.NewName == "_" {
+		res.Status = msg.Fatal
+		res.Errors = []string{fmt.Sprintf("invalid name: %q", m.NewName)}
+		return res
+	}
+
+	fset := token.NewFileSet()
+	tree, ok := parseFile(res, fset, m.Content)
+	if !ok || res.Status == msg.Error {
+		return res
+	}
+
+	file := fset.File(tree.FileStart)
+	offset, err := resolveOffset(file, m.Start)
+	if err != nil {
+		res.Status = msg.Fatal
+		res.Errors = []string{err.Error()}
+		return res
+	}
+
+	ident := identAt(tree, file.Pos(offset))
+	if ident == nil {
+		res.Status = msg.Fatal
+		res.Errors = []string{fmt.Sprintf("no identifier at %s", fset.Position(file.Pos(offset)))}
+		return res
+	}
+
+	keys, unknown := compositeKeys(tree)
+	if keys[ident] {
+		res.Status = msg.Fatal
+		res.Errors = []string{fmt.Sprintf("%s can't be renamed: it is a field name", ident.Name)}
+		return res
+	}
+
+	if ident.Obj == nil {
+		res.Status = msg.Fatal
+		res.Errors = []string{fmt.Sprintf("%s can't be renamed: it isn't declared in the file", ident.Name)}
+		return res
+	}
+
+	// the parser doesn't resolve the selectors of fields and methods to their declarations
+	if kind := memberKinds(tree)[ident.Obj.Decl]; kind != "" {
+		res.Status = msg.Fatal
+		res.Errors = []string{fmt.Sprintf("%s can't be renamed: it is a %s name", ident.Name, kind)}
+		return res
+	}
+
+	for key := range unknown {
+		if key == ident || key.Obj == ident.Obj {
+			res.Status = msg.Fatal
+			res.Errors = []string{fmt.Sprintf("%s can't be renamed: the key at %s may be a field name",
+				ident.Name, fset.Position(key.Pos()))}
+			return res
+		}
+	}
+
+	renamed := &msg.Renamed{Edits: []*msg.TextEdit{}}
+	var source strings.Builder
+	last := 0
+	ast.Inspect(tree, func(node ast.Node) bool {
+		ref, ok := node.(*ast.Ident)
+		if !ok || ref.Obj != ident.Obj || keys[ref] {
+			return true
+		}
+
+		source.WriteString(m.Content[last:file.Offset(ref.Pos())])
+		source.WriteString(m.NewName)
+		last = file.Offset(ref.End())
+		renamed.Edits = append(renamed.Edits, &msg.TextEdit{
+			Start:   getPosition(fset, ref.Pos()),
+			End:     getPosition(fset, ref.End()),
+			NewText: m.NewName,
+		})
+
+		return true
+	})
+
+	source.WriteString(m.Content[last:])
+	renamed.Source = source.String()
+
+	if conflicts := getRenameConflicts(fset, tree, m.Content, renamed.Source, ident.Name, m.NewName); len(conflicts) > 0 {
+		res.Status = msg.Fatal
+		res.Errors = conflicts
+		return res
+	}
+
+	res.Rename = renamed
+	return res
+}
+
+// identAt returns the identifier at a position, including its end, or nil if there is none.
+func identAt(tree *ast.File, pos token.Pos) *ast.Ident {
+	var found *ast.Ident
+	ast.Inspect(tree, func(node ast.Node) bool {
+		if found != nil || node == nil || pos < node.Pos() || pos > node.End() {
+			return false
+		}
+
+		if ident, ok := node.(*ast.Ident); ok {
+			found = ident
+		}
+
+		return true
+	})
+
+	return found
+}
+
+// getRenameConflicts returns the conflicts of renaming a content to source: the declaration errors
+// the rename adds, and the identifiers which would be resolved to another declaration, or to none.
+// The renamed tree has the same identifiers in the same order, so they are compared by index.
+func getRenameConflicts(fset *token.FileSet, tree *ast.File, content, source, oldName, newName string) []string {
+	oldErrors := make(map[string]bool)
+	_, err := parser.ParseFile(token.NewFileSet(), "source.go", content, parseMode|parser.DeclarationErrors)
+	if errList, ok := err.(scanner.ErrorList); ok {
+		for _, e := range errList {
+			oldErrors[e.Error()] = true
+		}
+	}
+
+	var conflicts []string
+	newTree, err := parser.ParseFile(token.NewFileSet(), "source.go", source, parseMode|parser.DeclarationErrors)
+	if errList, ok := err.(scanner.ErrorList); ok {
+		for _, e := range errList {
+			if !oldErrors[e.Error()] {
+				conflicts = append(conflicts, e.Error())
+			}
+		}
+	}
+
+	if newTree == nil {
+		return conflicts
+	}
+
+	oldIdents, oldDecls := resolvedDecls(tree)
+	_, newDecls := resolvedDecls(newTree)
+	if len(oldDecls) != len(newDecls) {
+		return append(conflicts, fmt.Sprintf("renaming %s to %s changes the syntax tree", oldName, newName))
+	}
+
+	for i, decl := range oldDecls {
+		if decl != newDecls[i] {
+			conflicts = append(conflicts, fmt.Sprintf("%s: %s would refer to another declaration after renaming %s to %s",
+				fset.Position(oldIdents[i].Pos()), oldIdents[i].Name, oldName, newName))
+		}
+	}
+
+	return conflicts
+}
+
+// memberKinds returns the fields of the struct types of a file, as "field", and the ones of its
+// interface types, as "method".
+func memberKinds(tree *ast.File) map[interface{}]string {
+	kinds := make(map[interface{}]string)
+	ast.Inspect(tree, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.StructType:
+			for _, field := range n.Fields.List {
+				kinds[field] = "field"
+			}
+		case *ast.InterfaceType:
+			for _, field := range n.Methods.List {
+				kinds[field] = "method"
+			}
+		}
+
+		return true
+	})
+
+	return kinds
+}
+
+// compositeKeys returns the keys of the composite literals of a file which are field names, and the
+// ones which may be, since the literal type can't be resolved in the file. The parser resolves keys
+// to any variable of the same name in scope, so they are told apart by the literal type: the keys of
+// struct types are field names, and the ones of array, slice and map types are expressions. The type
+// of a literal whose type is elided is the element type of the enclosing literal.
+func compositeKeys(tree *ast.File) (fields, unknown map[*ast.Ident]bool) {
+	fields = make(map[*ast.Ident]bool)
+	unknown = make(map[*ast.Ident]bool)
+	elided := make(map[*ast.CompositeLit]ast.Expr)
+	ast.Inspect(tree, func(node ast.Node) bool {
+		lit, ok := node.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+
+		typ := lit.Type
+		if typ == nil {
+			typ = elided[lit]
+		}
+
+		typ = underlyingType(typ)
+		for _, elt := range lit.Elts {
+			var key ast.Expr
+			value := elt
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				key, value = kv.Key, kv.Value
+			}
+
+			name, _ := key.(*ast.Ident)
+			// only the types of array, slice and map elements can be elided
+			var elem ast.Expr
+			switch t := typ.(type) {
+			case *ast.StructType:
+				if name != nil {
+					fields[name] = true
+				}
+			case *ast.ArrayType:
+				elem = t.Elt
+			case *ast.MapType:
+				elem = t.Value
+				if k, ok := key.(*ast.CompositeLit); ok && k.Type == nil {
+					elided[k] = elidedType(t.Key)
+				}
+			default:
+				if name != nil {
+					unknown[name] = true
+				}
+			}
+
+			if v, ok := value.(*ast.CompositeLit); ok && v.Type == nil {
+				elided[v] = elidedType(elem)
+			}
+		}
+
+		return true
+	})
+
+	return fields, unknown
+}
+
+// underlyingType returns the type literal a type expression is declared as in the file, or nil if
+// it can't be resolved.
+func underlyingType(typ ast.Expr) ast.Expr {
+	// a type declared as itself is invalid, so the loop is bounded
+	for i := 0; i < 100; i++ {
+		switch t := typ.(type) {
+		case *ast.ParenExpr:
+			typ = t.X
+		case *ast.IndexExpr:
+			typ = t.X
+		case *ast.IndexListExpr:
+			typ = t.X
+		case *ast.Ident:
+			if t.Obj == nil {
+				return nil
+			}
+
+			spec, ok := t.Obj.Decl.(*ast.TypeSpec)
+			if !ok {
+				return nil
+			}
+
+			typ = spec.Type
+		case *ast.StructType, *ast.ArrayType, *ast.MapType:
+			return typ
+		default:
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// elidedType returns the type of a composite literal whose type is elided in an enclosing literal
+// with elements of type elem: &T{} can be elided as {} in the elements of type *T.
+func elidedType(elem ast.Expr) ast.Expr {
+	if star, ok := elem.(*ast.StarExpr); ok {
+		return star.X
+	}
+
+	return elem
+}
+
+// resolvedDecls returns the identifiers of a file in source order, and for every one the index of
+// the identifier declaring the object it is resolved to. Unresolved identifiers, and the field keys
+// of composite literals, have a -1 index, and objects without a declaring identifier in the file a
+// -2 one.
+func resolvedDecls(tree *ast.File) ([]*ast.Ident, []int) {
+	var idents []*ast.Ident
+	index := make(map[token.Pos]int)
+	ast.Inspect(tree, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok {
+			index[ident.Pos()] = len(idents)
+			idents = append(idents, ident)
+		}
+
+		return true
+	})
+
+	keys, _ := compositeKeys(tree)
+	decls := make([]int, len(idents))
+	for i, ident := range idents {
+		if ident.Obj == nil || keys[ident] {
+			decls[i] = -1
+			continue
+		}
+
+		decl, ok := index[ident.Obj.Pos()]
+		if !ok {
+			decl = -2
+		}
+
+		decls[i] = decl
+	}
+
+	return idents, decls
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+const renameSource = `package foo
+
+import "fmt"
+
+func F(x int) int {
+	y := x + 1
+	{
+		x := 2
+		y += x
+	}
+	fmt.Println(x)
+	return x + y
+}
+`
+
+func TestGetRename(t *testing.T) {
+	res := getResponse(&msg.Request{
+		Action:  msg.Rename,
+		Content: renameSource,
+		Start:   &msg.Position{Line: 5, Column: 8},
+		NewName: "n",
+	})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Equal(t, `package foo
+
+import "fmt"
+
+func F(n int) int {
+	y := n + 1
+	{
+		x := 2
+		y += x
+	}
+	fmt.Println(n)
+	return n + y
+}
+`, res.Rename.Source)
+	require.Equal(t, []*msg.TextEdit{
+		{Start: msg.Position{Offset: 34, Line: 5, Column: 8}, End: msg.Position{Offset: 35, Line: 5, Column: 9}, NewText: "n"},
+		{Start: msg.Position{Offset: 53, Line: 6, Column: 7}, End: msg.Position{Offset: 54, Line: 6, Column: 8}, NewText: "n"},
+		{Start: msg.Position{Offset: 96, Line: 11, Column: 14}, End: msg.Position{Offset: 97, Line: 11, Column: 15}, NewText: "n"},
+		{Start: msg.Position{Offset: 107, Line: 12, Column: 9}, End: msg.Position{Offset: 108, Line: 12, Column: 10}, NewText: "n"},
+	}, res.Rename.Edits)
+
+	// the shadowed variable is renamed on its own
+	res = getResponse(&msg.Request{
+		Action:  msg.Rename,
+		Content: renameSource,
+		Start:   &msg.Position{Line: 9, Column: 8},
+		NewName: "z",
+	})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Len(t, res.Rename.Edits, 2)
+	require.Contains(t, res.Rename.Source, "\t\tz := 2\n\t\ty += z\n")
+}
+
+func TestGetRenameConflicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   msg.Position
+		newName string
+		errors  []string
+	}{
+		{"redeclared", msg.Position{Line: 6, Column: 2}, "x", []string{
+			"source.go:6:2: no new variables on left side of :=",
+			"source.go:6:2: y would refer to another declaration after renaming y to x",
+			"source.go:9:3: y would refer to another declaration after renaming y to x",
+			"source.go:12:13: y would refer to another declaration after renaming y to x",
+		}},
+		{"parameter", msg.Position{Line: 5, Column: 8}, "y", []string{
+			"source.go:6:2: no new variables on left side of :=",
+			"source.go:6:2: y would refer to another declaration after renaming x to y",
+			"source.go:9:3: y would refer to another declaration after renaming x to y",
+			"source.go:12:13: y would refer to another declaration after renaming x to y",
+		}},
+		{"package", msg.Position{Line: 5, Column: 8}, "fmt", []string{
+			"source.go:11:2: fmt would refer to another declaration after renaming x to fmt",
+		}},
+		{"shadowing", msg.Position{Line: 8, Column: 3}, "y", []string{
+			"source.go:9:3: y would refer to another declaration after renaming x to y",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start := test.start
+			res := getResponse(&msg.Request{Action: msg.Rename, Content: renameSource, Start: &start, NewName: test.newName})
+			require.Equal(t, msg.Fatal, res.Status)
+			require.Nil(t, res.Rename)
+			require.Equal(t, test.errors, res.Errors)
+		})
+	}
+}
+
+func TestGetRenameInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   *msg.Position
+		newName string
+		err     string
+	}{
+		{"no start", nil, "a", "no start position in the request"},
+		{"keyword", &msg.Position{Line: 5, Column: 8}, "func", `invalid name: "func"`},
+		{"blank", &msg.Position{Line: 5, Column: 8}, "_", `invalid name: "_"`},
+		{"no identifier", &msg.Position{Line: 5, Column: 1}, "a", "no identifier at source.go:5:1"},
+		{"imported", &msg.Position{Line: 11, Column: 6}, "a", "Println can't be renamed: it isn't declared in the file"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := getResponse(&msg.Request{Action: msg.Rename, Content: renameSource, Start: test.start, NewName: test.newName})
+			require.Equal(t, msg.Fatal, res.Status)
+			require.Equal(t, []string{test.err}, res.Errors)
+		})
+	}
+}
+
+func TestGetRenameCompositeKeys(t *testing.T) {
+	content := `package foo
+
+type T struct{ name string }
+
+type M map[string]int
+
+func F() (T, M) {
+	name := "x"
+	return T{name: name}, M{name: 1}
+}
+`
+	res := getResponse(&msg.Request{
+		Action:  msg.Rename,
+		Content: content,
+		Start:   &msg.Position{Line: 8, Column: 2},
+		NewName: "label",
+	})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Contains(t, res.Rename.Source, "\tlabel := \"x\"\n\treturn T{name: label}, M{label: 1}\n")
+	require.Len(t, res.Rename.Edits, 3)
+
+	res = getResponse(&msg.Request{
+		Action:  msg.Rename,
+		Content: content,
+		Start:   &msg.Position{Line: 9, Column: 11},
+		NewName: "label",
+	})
+	require.Equal(t, msg.Fatal, res.Status)
+	require.Equal(t, []string{"name can't be renamed: it is a field name"}, res.Errors)
+}
+
+func TestGetRenameMembers(t *testing.T) {
+	content := `package foo
+
+type T struct{ F int }
+
+type I interface{ M() }
+
+func G(t T, i I) int {
+	i.M()
+	return t.F
+}
+`
+	tests := []struct {
+		name  string
+		start msg.Position
+		err   string
+	}{
+		{"field", msg.Position{Line: 3, Column: 16}, "F can't be renamed: it is a field name"},
+		{"method", msg.Position{Line: 5, Column: 19}, "M can't be renamed: it is a method name"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start := test.start
+			res := getResponse(&msg.Request{Action: msg.Rename, Content: content, Start: &start, NewName: "X"})
+			require.Equal(t, msg.Fatal, res.Status)
+			require.Nil(t, res.Rename)
+			require.Equal(t, []string{test.err}, res.Errors)
+		})
+	}
+
+	// parameters are declared by fields too
+	res := getResponse(&msg.Request{Action: msg.Rename, Content: content, Start: &msg.Position{Line: 7, Column: 8}, NewName: "v"})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Contains(t, res.Rename.Source, "\treturn v.F\n")
+}
+
+func TestGetRenameCompositeKeyTypes(t *testing.T) {
+	content := `package foo
+
+import "strings"
+
+const N = 1
+
+type Set = map[string]bool
+
+type P struct{ X int }
+
+func F(name string) interface{} {
+	return []interface{}{
+		[...]string{N: "x"},
+		Set{name: true},
+		[]*P{{X: 1}},
+		map[P][]P{{X: 2}: {{X: 3}}},
+		strings.Builder{},
+	}
+}
+
+func G(X int) strings.Reader {
+	return strings.Reader{X: 1}
+}
+`
+	rename := func(line, column int) *msg.Response {
+		return getResponse(&msg.Request{
+			Action:  msg.Rename,
+			Content: content,
+			Start:   &msg.Position{Line: line, Column: column},
+			NewName: "Z",
+		})
+	}
+
+	// array index
+	res := rename(5, 7)
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Contains(t, res.Rename.Source, "const Z = 1\n")
+	require.Contains(t, res.Rename.Source, "[...]string{Z: \"x\"},\n")
+
+	// map by alias
+	res = rename(11, 8)
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Contains(t, res.Rename.Source, "\t\tSet{Z: true},\n")
+
+	// elided nested literals, whose keys are fields
+	res = rename(15, 9)
+	require.Equal(t, msg.Fatal, res.Status)
+	require.Equal(t, []string{"X can't be renamed: it is a field name"}, res.Errors)
+
+	res = rename(16, 14)
+	require.Equal(t, msg.Fatal, res.Status)
+	require.Equal(t, []string{"X can't be renamed: it is a field name"}, res.Errors)
+
+	res = rename(16, 23)
+	require.Equal(t, msg.Fatal, res.Status)
+	require.Equal(t, []string{"X can't be renamed: it is a field name"}, res.Errors)
+
+	// a type declared in another package
+	res = rename(21, 8)
+	require.Equal(t, msg.Fatal, res.Status)
+	require.Equal(t, []string{"X can't be renamed: the key at source.go:22:24 may be a field name"}, res.Errors)
+}
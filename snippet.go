@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"reflect"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+)
+
+// posType is the type of the position fields of the AST nodes.
+var posType = reflect.TypeOf(token.NoPos)
+
+// snippetWrappers are the prefix and suffix which turn a fragment into a whole file. The prefixes end
+// with a newline, so the first line of the fragment can hold doc comments.
+var snippetWrappers = map[string][2]string{
+	msg.ModeStmts: {"package p\nfunc _() {\n", "\n}\n"},
+	msg.ModeDecls: {"package p\n", ""},
+}
+
+// getSnippet replies a msg.ParseAst request of a fragment parsed with a snippet mode. The positions
+// of the nodes and the errors are remapped to the original fragment. Statements with an unbalanced }
+// are replied with msg.Fatal status, since it would close the function they are parsed in.
+func getSnippet(m *msg.Request) *msg.Response {
+	res := newResponse()
+	fset := token.NewFileSet()
+	if m.Mode == msg.ModeExpr {
+		expr, err := parser.ParseExprFrom(fset, "source.go", m.Content, parseMode)
+		if !setSnippetStatus(res, m.Content, 0, expr != nil, err) {
+			return res
+		}
+
+		if m.Identifiers {
+			res.Identifiers = getIdentifiers(fset, expr)
+		}
+
+		ast.Inspect(expr, setObjNil)
+		res.Snippet = &msg.Snippet{Expr: expr}
+		return res
+	}
+
+	wrapper := snippetWrappers[m.Mode]
+	tree, err := parser.ParseFile(fset, "source.go", wrapper[0]+m.Content+wrapper[1], parseMode)
+	if !setSnippetStatus(res, m.Content, len(wrapper[0]), tree != nil, err) {
+		return res
+	}
+
+	res.Snippet = &msg.Snippet{Comments: tree.Comments}
+	if m.Mode == msg.ModeDecls {
+		res.Snippet.Decls = tree.Decls
+	} else if len(tree.Decls) > 0 {
+		if fn, ok := tree.Decls[0].(*ast.FuncDecl); ok && fn.Body != nil {
+			// an unbalanced } closes the wrapper function, leaving the rest of the fragment out
+			end := fset.File(tree.FileStart).Offset(fn.Body.Rbrace) - len(wrapper[0])
+			if fn.Body.Rbrace.IsValid() && end < len(m.Content) {
+				file := token.NewFileSet().AddFile("source.go", -1, len(m.Content))
+				file.SetLinesForContent([]byte(m.Content))
+				res.Status = msg.Fatal
+				res.Errors = []string{fmt.Sprintf("%s: unbalanced } in the statements", file.Position(file.Pos(end)))}
+				res.Snippet = nil
+				return res
+			}
+
+			res.Snippet.Stmts = fn.Body.List
+		}
+	}
+
+	shifted := make(map[ast.Node]bool)
+	shift := func(node ast.Node) bool {
+		if node == nil || shifted[node] {
+			return false
+		}
+
+		shifted[node] = true
+		shiftPos(node, token.Pos(len(wrapper[0])))
+		return true
+	}
+
+	for _, decl := range res.Snippet.Decls {
+		ast.Inspect(decl, shift)
+	}
+
+	for _, stmt := range res.Snippet.Stmts {
+		ast.Inspect(stmt, shift)
+	}
+
+	for _, group := range res.Snippet.Comments {
+		ast.Inspect(group, shift)
+	}
+
+	if m.Identifiers {
+		// the shifted positions are the ones of a file holding just the fragment
+		fragment := token.NewFileSet()
+		fragment.AddFile("source.go", -1, len(m.Content)).SetLinesForContent([]byte(m.Content))
+		var nodes []ast.Node
+		for _, decl := range res.Snippet.Decls {
+			nodes = append(nodes, decl)
+		}
+
+		for _, stmt := range res.Snippet.Stmts {
+			nodes = append(nodes, stmt)
+		}
+
+		res.Identifiers = getIdentifiers(fragment, nodes...)
+	}
+
+	ast.Inspect(tree, setObjNil)
+	return res
+}
+
+// setSnippetStatus sets the status and the errors of a snippet response. The errors are remapped
+// to the fragment, whose text starts at offset in the parsed source. If the returned bool is false
+// the response is fatal.
+func setSnippetStatus(res *msg.Response, fragment string, offset int, parsed bool, err error) bool {
+	if err == nil {
+		res.Status = msg.Ok
+		return true
+	}
+
+	errList, ok := err.(scanner.ErrorList)
+	if !parsed || !ok {
+		res.Status = msg.Fatal
+		res.Errors = []string{err.Error()}
+		return false
+	}
+
+	file := token.NewFileSet().AddFile("source.go", -1, len(fragment))
+	file.SetLinesForContent([]byte(fragment))
+	for _, e := range errList {
+		off := e.Pos.Offset - offset
+		if off < 0 {
+			off = 0
+		} else if off > len(fragment) {
+			off = len(fragment)
+		}
+
+		e.Pos = file.Position(file.Pos(off))
+	}
+
+	res.Status = msg.Error
+	res.Errors = getErrors(errList)
+	return true
+}
+
+// shiftPos moves back by delta every valid token.Pos field of a node.
+func shiftPos(node ast.Node, delta token.Pos) {
+	v := reflect.ValueOf(node)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return
+	}
+
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if f.Type() != posType || !f.CanSet() {
+			continue
+		}
+
+		if pos := token.Pos(f.Int()); pos.IsValid() {
+			f.SetInt(int64(pos - delta))
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSnippetExpr(t *testing.T) {
+	res := getResponse(&msg.Request{Action: msg.ParseAst, Mode: msg.ModeExpr, Content: "a + f(b)"})
+	require.Equal(t, msg.Ok, res.Status)
+	require.Nil(t, res.AST)
+
+	expr, ok := res.Snippet.Expr.(*ast.BinaryExpr)
+	require.True(t, ok)
+	require.Equal(t, token.Pos(1), expr.Pos())
+	require.Equal(t, token.Pos(9), expr.End())
+	require.Equal(t, token.Pos(3), expr.OpPos)
+}
+
+func TestGetSnippetStmts(t *testing.T) {
+	source := "x := 1 // one\nif x > 0 {\n\tx++\n}"
+	res := getResponse(&msg.Request{Action: msg.ParseAst, Mode: msg.ModeStmts, Content: source})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Len(t, res.Snippet.Stmts, 2)
+
+	assign := res.Snippet.Stmts[0].(*ast.AssignStmt)
+	require.Equal(t, token.Pos(1), assign.Pos())
+	require.Equal(t, token.Pos(3), assign.TokPos)
+
+	ifStmt := res.Snippet.Stmts[1].(*ast.IfStmt)
+	require.Equal(t, token.Pos(15), ifStmt.If)
+	require.Equal(t, token.Pos(len(source)+1), ifStmt.End())
+
+	require.Len(t, res.Snippet.Comments, 1)
+	require.Equal(t, token.Pos(8), res.Snippet.Comments[0].Pos())
+}
+
+func TestGetSnippetDecls(t *testing.T) {
+	source := "// F does nothing.\nfunc F() {}\n\ntype T int"
+	res := getResponse(&msg.Request{Action: msg.ParseAst, Mode: msg.ModeDecls, Content: source})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Len(t, res.Snippet.Decls, 2)
+
+	fn := res.Snippet.Decls[0].(*ast.FuncDecl)
+	require.Equal(t, token.Pos(1), fn.Doc.Pos())
+	require.Equal(t, token.Pos(20), fn.Pos())
+	require.Equal(t, token.Pos(len(source)+1), res.Snippet.Decls[1].End())
+	require.Len(t, res.Snippet.Comments, 1)
+}
+
+func TestGetSnippetErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		mode   string
+		source string
+		first  string
+	}{
+		{
+			name:   "expr",
+			mode:   msg.ModeExpr,
+			source: "a +",
+			first:  "source.go:1:4: expected operand, found 'EOF'",
+		},
+		{
+			name:   "stmts",
+			mode:   msg.ModeStmts,
+			source: "x := )",
+			first:  "source.go:1:6: expected operand, found ')'",
+		},
+		{
+			name:   "decls",
+			mode:   msg.ModeDecls,
+			source: "func F() {}\nvar = 1",
+			first:  "source.go:2:5: expected 'IDENT', found '='",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := getResponse(&msg.Request{Action: msg.ParseAst, Mode: test.mode, Content: test.source})
+			require.Equal(t, msg.Error, res.Status)
+			require.NotEmpty(t, res.Errors)
+			require.Equal(t, test.first, res.Errors[0])
+			require.NotNil(t, res.Snippet)
+		})
+	}
+}
+
+func TestGetParseASTUnknownMode(t *testing.T) {
+	res := getResponse(&msg.Request{Action: msg.ParseAst, Mode: "line"})
+	require.Equal(t, msg.Fatal, res.Status)
+}
+
+func TestGetSnippetUnbalancedStmts(t *testing.T) {
+	source := "f()\n}\n\nfunc g() {\n\tg()"
+	res := getResponse(&msg.Request{Action: msg.ParseAst, Mode: msg.ModeStmts, Content: source})
+	require.Equal(t, msg.Fatal, res.Status)
+	require.Equal(t, []string{"source.go:2:1: unbalanced } in the statements"}, res.Errors)
+	require.Nil(t, res.Snippet)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"go/token"
+	"sort"
+	tmpl "text/template/parse"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+)
+
+// templateBuiltins are the functions predefined by text/template.
+var templateBuiltins = []string{
+	"and", "call", "html", "index", "slice", "js", "len", "not", "or", "print", "printf",
+	"println", "urlquery", "eq", "ge", "gt", "le", "lt", "ne",
+}
+
+// getParseTemplate replies a msg.ParseTemplate request with the parse trees of the template in the
+// content and the ones it defines. Besides the builtins, only the functions in m.Funcs can be
+// called. The template parser stops at the first error, which is replied with msg.Error status.
+func getParseTemplate(m *msg.Request) *msg.Response {
+	res := newResponse()
+	// the parser only checks the functions are defined, so any non-nil value will do
+	funcs := make(map[string]interface{})
+	for _, name := range templateBuiltins {
+		funcs[name] = true
+	}
+
+	for _, name := range m.Funcs {
+		funcs[name] = true
+	}
+
+	tree := tmpl.New("source.tmpl")
+	tree.Mode = tmpl.ParseComments
+	trees := make(map[string]*tmpl.Tree)
+	if _, err := tree.Parse(m.Content, "", "", trees, funcs); err != nil {
+		res.Status = msg.Error
+		res.Errors = []string{err.Error()}
+		return res
+	}
+
+	file := token.NewFileSet().AddFile("source.tmpl", -1, len(m.Content))
+	file.SetLinesForContent([]byte(m.Content))
+
+	res.Status = msg.Ok
+	res.Templates = []*msg.Template{}
+	for name, t := range trees {
+		res.Templates = append(res.Templates, &msg.Template{
+			Name: name,
+			Root: getTemplateNode(file, t.Root),
+		})
+	}
+
+	sortTemplates(res.Templates)
+	return res
+}
+
+// sortTemplates sorts templates by the offset of their root, and then by name, since associated
+// and empty templates may share offsets and the templates are collected from a map.
+func sortTemplates(list []*msg.Template) {
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.Root.Start.Offset != b.Root.Start.Offset {
+			return a.Root.Start.Offset < b.Root.Start.Offset
+		}
+
+		return a.Name < b.Name
+	})
+}
+
+// getTemplateNode converts a template tmpl.Node in a msg.TemplateNode. Positions are resolved
+// with the lines of the content in file.
+func getTemplateNode(file *token.File, node tmpl.Node) *msg.TemplateNode {
+	n := &msg.TemplateNode{Start: getOffsetPosition(file, int(node.Position()))}
+	switch node := node.(type) {
+	case *tmpl.ListNode:
+		n.Type = "list"
+		for _, child := range node.Nodes {
+			n.Nodes = append(n.Nodes, getTemplateNode(file, child))
+		}
+	case *tmpl.TextNode:
+		n.Type = "text"
+		n.Value = string(node.Text)
+	case *tmpl.CommentNode:
+		n.Type = "comment"
+		n.Value = node.Text
+	case *tmpl.ActionNode:
+		n.Type = "action"
+		n.Pipe = getTemplateNode(file, node.Pipe)
+	case *tmpl.PipeNode:
+		n.Type = "pipeline"
+		if len(node.Decl) > 0 {
+			n.Value = ":="
+			if node.IsAssign {
+				n.Value = "="
+			}
+		}
+
+		for _, v := range node.Decl {
+			n.Decl = append(n.Decl, getTemplateNode(file, v))
+		}
+
+		for _, cmd := range node.Cmds {
+			n.Nodes = append(n.Nodes, getTemplateNode(file, cmd))
+		}
+	case *tmpl.CommandNode:
+		n.Type = "command"
+		for _, arg := range node.Args {
+			n.Nodes = append(n.Nodes, getTemplateNode(file, arg))
+		}
+	case *tmpl.FieldNode:
+		n.Type = "field"
+		n.Ident = node.Ident
+	case *tmpl.VariableNode:
+		n.Type = "variable"
+		n.Ident = node.Ident
+	case *tmpl.ChainNode:
+		n.Type = "chain"
+		n.Ident = node.Field
+		n.Nodes = []*msg.TemplateNode{getTemplateNode(file, node.Node)}
+	case *tmpl.IdentifierNode:
+		n.Type = "identifier"
+		n.Value = node.Ident
+	case *tmpl.DotNode:
+		n.Type = "dot"
+	case *tmpl.NilNode:
+		n.Type = "nil"
+	case *tmpl.BoolNode:
+		n.Type = "bool"
+		n.Value = node.String()
+	case *tmpl.NumberNode:
+		n.Type = "number"
+		n.Value = node.Text
+	case *tmpl.StringNode:
+		n.Type = "string"
+		n.Value = node.Text
+	case *tmpl.IfNode:
+		n.Type = "if"
+		setTemplateBranch(file, n, &node.BranchNode)
+	case *tmpl.RangeNode:
+		n.Type = "range"
+		setTemplateBranch(file, n, &node.BranchNode)
+	case *tmpl.WithNode:
+		n.Type = "with"
+		setTemplateBranch(file, n, &node.BranchNode)
+	case *tmpl.TemplateNode:
+		n.Type = "template"
+		n.Value = node.Name
+		if node.Pipe != nil {
+			n.Pipe = getTemplateNode(file, node.Pipe)
+		}
+	case *tmpl.BreakNode:
+		n.Type = "break"
+	case *tmpl.ContinueNode:
+		n.Type = "continue"
+	}
+
+	return n
+}
+
+// setTemplateBranch sets the pipeline and the bodies of an if, range or with node.
+func setTemplateBranch(file *token.File, n *msg.TemplateNode, branch *tmpl.BranchNode) {
+	n.Pipe = getTemplateNode(file, branch.Pipe)
+	n.List = getTemplateNode(file, branch.List)
+	if branch.ElseList != nil {
+		n.ElseList = getTemplateNode(file, branch.ElseList)
+	}
+}
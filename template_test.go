@@ -0,0 +1,101 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetParseTemplate(t *testing.T) {
+	source := `{{/* list */}}{{define "item"}}<li>{{.Name | upper}}</li>{{end}}
+{{range $i, $x := .Items}}{{template "item" $x}}{{else}}empty{{end}}`
+
+	res := getResponse(&msg.Request{Action: msg.ParseTemplate, Content: source, Funcs: []string{"upper"}})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.Len(t, res.Templates, 2)
+
+	item := res.Templates[1]
+	require.Equal(t, "item", item.Name)
+	require.Equal(t, &msg.TemplateNode{
+		Type:  "list",
+		Start: msg.Position{Offset: 31, Line: 1, Column: 32},
+		Nodes: []*msg.TemplateNode{
+			{Type: "text", Start: msg.Position{Offset: 31, Line: 1, Column: 32}, Value: "<li>"},
+			{
+				Type:  "action",
+				Start: msg.Position{Offset: 37, Line: 1, Column: 38},
+				Pipe: &msg.TemplateNode{
+					Type:  "pipeline",
+					Start: msg.Position{Offset: 37, Line: 1, Column: 38},
+					Nodes: []*msg.TemplateNode{
+						{
+							Type:  "command",
+							Start: msg.Position{Offset: 37, Line: 1, Column: 38},
+							Nodes: []*msg.TemplateNode{
+								{Type: "field", Start: msg.Position{Offset: 37, Line: 1, Column: 38}, Ident: []string{"Name"}},
+							},
+						},
+						{
+							Type:  "command",
+							Start: msg.Position{Offset: 45, Line: 1, Column: 46},
+							Nodes: []*msg.TemplateNode{
+								{Type: "identifier", Start: msg.Position{Offset: 45, Line: 1, Column: 46}, Value: "upper"},
+							},
+						},
+					},
+				},
+			},
+			{Type: "text", Start: msg.Position{Offset: 52, Line: 1, Column: 53}, Value: "</li>"},
+		},
+	}, item.Root)
+
+	main := res.Templates[0]
+	require.Equal(t, "source.tmpl", main.Name)
+	require.Len(t, main.Root.Nodes, 3)
+	require.Equal(t, "comment", main.Root.Nodes[0].Type)
+	require.Equal(t, "/* list */", main.Root.Nodes[0].Value)
+
+	rng := main.Root.Nodes[2]
+	require.Equal(t, "range", rng.Type)
+	require.Equal(t, 2, rng.Start.Line)
+	require.Equal(t, ":=", rng.Pipe.Value)
+	require.Len(t, rng.Pipe.Decl, 2)
+	require.Equal(t, []string{"$x"}, rng.Pipe.Decl[1].Ident)
+	require.Equal(t, "template", rng.List.Nodes[0].Type)
+	require.Equal(t, "item", rng.List.Nodes[0].Value)
+	require.Equal(t, "empty", rng.ElseList.Nodes[0].Value)
+}
+
+func TestGetParseTemplateErrors(t *testing.T) {
+	res := getResponse(&msg.Request{Action: msg.ParseTemplate, Content: "{{.Name | upper}}"})
+	require.Equal(t, msg.Error, res.Status)
+	require.Equal(t, []string{`template: source.tmpl:1: function "upper" not defined`}, res.Errors)
+	require.Nil(t, res.Templates)
+
+	res = getResponse(&msg.Request{Action: msg.ParseTemplate, Content: "line\n{{if .X}}"})
+	require.Equal(t, msg.Error, res.Status)
+	require.Equal(t, []string{"template: source.tmpl:2: unexpected EOF"}, res.Errors)
+}
+
+func TestSortTemplates(t *testing.T) {
+	root := func(offset int) *msg.TemplateNode {
+		return &msg.TemplateNode{Start: msg.Position{Offset: offset}}
+	}
+
+	list := []*msg.Template{
+		{Name: "c", Root: root(4)},
+		{Name: "b", Root: root(4)},
+		{Name: "z", Root: root(0)},
+		{Name: "a", Root: root(4)},
+	}
+
+	sortTemplates(list)
+	var names []string
+	for _, tmpl := range list {
+		names = append(names, tmpl.Name)
+	}
+
+	require.Equal(t, []string{"z", "a", "b", "c"}, names)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"go/scanner"
+	"go/token"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+)
+
+// getTokenize replies a msg.Tokenize request with the tokens of the content, including the
+// semicolons inserted automatically. Comments are only included if m.Comments is set. Scanner errors
+// don't stop the scan and are replied with msg.Error status.
+func getTokenize(m *msg.Request) *msg.Response {
+	res := newResponse()
+	res.Tokens = []*msg.Token{}
+
+	fset := token.NewFileSet()
+	src := []byte(m.Content)
+	file := fset.AddFile("source.go", fset.Base(), len(src))
+
+	var mode scanner.Mode
+	if m.Comments {
+		mode = scanner.ScanComments
+	}
+
+	var errList scanner.ErrorList
+	var s scanner.Scanner
+	s.Init(file, src, errList.Add, mode)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		res.Tokens = append(res.Tokens, &msg.Token{
+			Kind:     tok.String(),
+			Literal:  lit,
+			Start:    getPosition(fset, pos),
+			Inserted: tok == token.SEMICOLON && lit != ";",
+		})
+	}
+
+	if len(errList) > 0 {
+		res.Status = msg.Error
+		res.Errors = getErrors(errList)
+	} else {
+		res.Status = msg.Ok
+	}
+
+	return res
+}
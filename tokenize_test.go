@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTokenize(t *testing.T) {
+	source := "package main // comment\nvar x = 1"
+	tests := []struct {
+		name     string
+		comments bool
+		want     []*msg.Token
+	}{
+		{
+			name: "without comments",
+			want: []*msg.Token{
+				{Kind: "package", Literal: "package", Start: msg.Position{Offset: 0, Line: 1, Column: 1}},
+				{Kind: "IDENT", Literal: "main", Start: msg.Position{Offset: 8, Line: 1, Column: 9}},
+				{Kind: ";", Literal: "\n", Start: msg.Position{Offset: 23, Line: 1, Column: 24}, Inserted: true},
+				{Kind: "var", Literal: "var", Start: msg.Position{Offset: 24, Line: 2, Column: 1}},
+				{Kind: "IDENT", Literal: "x", Start: msg.Position{Offset: 28, Line: 2, Column: 5}},
+				{Kind: "=", Start: msg.Position{Offset: 30, Line: 2, Column: 7}},
+				{Kind: "INT", Literal: "1", Start: msg.Position{Offset: 32, Line: 2, Column: 9}},
+				{Kind: ";", Literal: "\n", Start: msg.Position{Offset: 33, Line: 2, Column: 10}, Inserted: true},
+			},
+		},
+		{
+			name:     "with comments",
+			comments: true,
+			want: []*msg.Token{
+				{Kind: "package", Literal: "package", Start: msg.Position{Offset: 0, Line: 1, Column: 1}},
+				{Kind: "IDENT", Literal: "main", Start: msg.Position{Offset: 8, Line: 1, Column: 9}},
+				{Kind: "COMMENT", Literal: "// comment", Start: msg.Position{Offset: 13, Line: 1, Column: 14}},
+				{Kind: ";", Literal: "\n", Start: msg.Position{Offset: 23, Line: 1, Column: 24}, Inserted: true},
+				{Kind: "var", Literal: "var", Start: msg.Position{Offset: 24, Line: 2, Column: 1}},
+				{Kind: "IDENT", Literal: "x", Start: msg.Position{Offset: 28, Line: 2, Column: 5}},
+				{Kind: "=", Start: msg.Position{Offset: 30, Line: 2, Column: 7}},
+				{Kind: "INT", Literal: "1", Start: msg.Position{Offset: 32, Line: 2, Column: 9}},
+				{Kind: ";", Literal: "\n", Start: msg.Position{Offset: 33, Line: 2, Column: 10}, Inserted: true},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := getResponse(&msg.Request{Action: msg.Tokenize, Content: source, Comments: test.comments})
+			require.Equal(t, msg.Ok, res.Status)
+			require.Equal(t, test.want, res.Tokens)
+		})
+	}
+}
+
+func TestGetTokenizeErrors(t *testing.T) {
+	res := getResponse(&msg.Request{Action: msg.Tokenize, Content: "x := 'ab'"})
+	require.Equal(t, msg.Error, res.Status)
+	require.Equal(t, []string{"source.go:1:6: illegal rune literal"}, res.Errors)
+	require.NotEmpty(t, res.Tokens)
+}
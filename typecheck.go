@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+)
+
+// stdImporter imports the standard library packages by type-checking their sources in GOROOT, so
+// it doesn't need network access nor compiled export data, but the sources must be installed: the
+// Docker image ships them in /usr/local/go and disables cgo, so the go command isn't needed either.
+// Imported packages are cached between requests.
+var stdImporter = &offlineImporter{
+	importer: importer.ForCompiler(token.NewFileSet(), "source", nil),
+}
+
+// offlineImporter is a types.Importer that only resolves standard library packages.
+type offlineImporter struct {
+	importer types.Importer
+}
+
+// Import implements types.Importer.
+func (i *offlineImporter) Import(path string) (*types.Package, error) {
+	if !isStdPath(path) {
+		return nil, fmt.Errorf("%q is not a standard library package", path)
+	}
+
+	return i.importer.Import(path)
+}
+
+// isStdPath reports whether an import path belongs to the standard library. As the go command
+// does, paths whose first element has no dot are considered standard.
+func isStdPath(path string) bool {
+	elem := path
+	if i := strings.Index(path, "/"); i >= 0 {
+		elem = path[:i]
+	}
+
+	return !strings.Contains(elem, ".")
+}
+
+// getTypeCheck replies a msg.TypeCheck request with the AST and the type information of the
+// content. Type errors, including unresolved imports, are replied as errors with msg.Error status.
+func getTypeCheck(m *msg.Request) *msg.Response {
+	res := newResponse()
+	fset := token.NewFileSet()
+	tree, ok := parseFile(res, fset, m.Content)
+	if !ok {
+		return res
+	}
+
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+
+	var typeErrors []types.Error
+	conf := &types.Config{
+		Importer: stdImporter,
+		Error: func(err error) {
+			typeErrors = append(typeErrors, err.(types.Error))
+		},
+	}
+
+	pkg, _ := conf.Check(tree.Name.Name, fset, []*ast.File{tree}, info)
+	sort.SliceStable(typeErrors, func(i, j int) bool {
+		a, b := typeErrors[i], typeErrors[j]
+		if a.Pos != b.Pos {
+			return a.Pos < b.Pos
+		}
+
+		return a.Msg < b.Msg
+	})
+
+	for _, err := range typeErrors {
+		res.Status = msg.Error
+		res.Errors = append(res.Errors, err.Error())
+	}
+
+	res.TypeInfo = getTypeInfo(fset, pkg, info)
+
+	ast.Inspect(tree, setObjNil)
+	res.AST = tree
+
+	return res
+}
+
+// getTypeInfo converts a types.Info in a msg.TypeInfo sorted by position.
+func getTypeInfo(fset *token.FileSet, pkg *types.Package, info *types.Info) *msg.TypeInfo {
+	qualifier := types.RelativeTo(pkg)
+	typeInfo := &msg.TypeInfo{Package: pkg.Name()}
+
+	for expr, tv := range info.Types {
+		node := &msg.TypedNode{
+			Node:  nodeType(expr),
+			Start: getPosition(fset, expr.Pos()),
+			End:   getPosition(fset, expr.End()),
+			Mode:  getMode(tv),
+		}
+
+		if tv.Type != nil {
+			node.Type = types.TypeString(tv.Type, qualifier)
+		}
+
+		if tv.Value != nil {
+			node.Value = tv.Value.ExactString()
+		}
+
+		typeInfo.Types = append(typeInfo.Types, node)
+	}
+
+	for id, obj := range info.Defs {
+		if obj == nil {
+			// package name and symbolic variables of type switches
+			continue
+		}
+
+		typeInfo.Defs = append(typeInfo.Defs, getObject(fset, pkg, id, obj))
+	}
+
+	for id, obj := range info.Uses {
+		typeInfo.Uses = append(typeInfo.Uses, getObject(fset, pkg, id, obj))
+	}
+
+	for sel, s := range info.Selections {
+		typeInfo.Selections = append(typeInfo.Selections, &msg.Selection{
+			Start:    getPosition(fset, sel.Pos()),
+			End:      getPosition(fset, sel.End()),
+			Kind:     getSelectionKind(s.Kind()),
+			Recv:     types.TypeString(s.Recv(), qualifier),
+			Obj:      getObject(fset, pkg, sel.Sel, s.Obj()),
+			Indirect: s.Indirect(),
+		})
+	}
+
+	// ties are broken by every other field, since the lists are built from maps
+	sort.Slice(typeInfo.Types, func(i, j int) bool {
+		a, b := typeInfo.Types[i], typeInfo.Types[j]
+		switch {
+		case a.Start.Offset != b.Start.Offset:
+			return a.Start.Offset < b.Start.Offset
+		case a.End.Offset != b.End.Offset:
+			return a.End.Offset > b.End.Offset
+		case a.Node != b.Node:
+			return a.Node < b.Node
+		case a.Type != b.Type:
+			return a.Type < b.Type
+		case a.Mode != b.Mode:
+			return a.Mode < b.Mode
+		default:
+			return a.Value < b.Value
+		}
+	})
+	sortObjects(typeInfo.Defs)
+	sortObjects(typeInfo.Uses)
+	sort.Slice(typeInfo.Selections, func(i, j int) bool {
+		a, b := typeInfo.Selections[i], typeInfo.Selections[j]
+		if a.Start.Offset != b.Start.Offset {
+			return a.Start.Offset < b.Start.Offset
+		}
+
+		return a.End.Offset < b.End.Offset
+	})
+
+	return typeInfo
+}
+
+// getObject builds a msg.Object from an identifier and the types.Object it denotes.
+func getObject(fset *token.FileSet, pkg *types.Package, id *ast.Ident, obj types.Object) *msg.Object {
+	qualifier := types.RelativeTo(pkg)
+	o := &msg.Object{
+		Name:  id.Name,
+		Start: getPosition(fset, id.Pos()),
+		Kind:  getObjectKind(obj),
+	}
+
+	if obj.Type() != nil {
+		o.Type = types.TypeString(obj.Type(), qualifier)
+	}
+
+	if obj.Pkg() != nil {
+		o.Package = obj.Pkg().Path()
+	}
+
+	if pkgName, ok := obj.(*types.PkgName); ok {
+		o.Package = pkgName.Imported().Path()
+	}
+
+	// positions of imported objects belong to the importer file set
+	if obj.Pkg() == pkg && obj.Pos().IsValid() {
+		decl := getPosition(fset, obj.Pos())
+		o.Decl = &decl
+	}
+
+	return o
+}
+
+// sortObjects sorts a []*msg.Object by position, and then by name, kind and type.
+func sortObjects(list []*msg.Object) {
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		switch {
+		case a.Start.Offset != b.Start.Offset:
+			return a.Start.Offset < b.Start.Offset
+		case a.Name != b.Name:
+			return a.Name < b.Name
+		case a.Kind != b.Kind:
+			return a.Kind < b.Kind
+		default:
+			return a.Type < b.Type
+		}
+	})
+}
+
+// getObjectKind returns the msg.Object kind of a types.Object.
+func getObjectKind(obj types.Object) string {
+	switch obj.(type) {
+	case *types.Var:
+		return "var"
+	case *types.Const:
+		return "const"
+	case *types.TypeName:
+		return "type"
+	case *types.Func:
+		return "func"
+	case *types.PkgName:
+		return "package"
+	case *types.Label:
+		return "label"
+	case *types.Builtin:
+		return "builtin"
+	case *types.Nil:
+		return "nil"
+	default:
+		return ""
+	}
+}
+
+// getSelectionKind returns the msg.Selection kind of a types.SelectionKind.
+func getSelectionKind(kind types.SelectionKind) string {
+	switch kind {
+	case types.FieldVal:
+		return "field"
+	case types.MethodVal:
+		return "method"
+	case types.MethodExpr:
+		return "methodexpr"
+	default:
+		return ""
+	}
+}
+
+// getMode returns the msg.TypedNode mode of a types.TypeAndValue.
+func getMode(tv types.TypeAndValue) string {
+	switch {
+	case tv.IsVoid():
+		return "void"
+	case tv.IsType():
+		return "type"
+	case tv.IsBuiltin():
+		return "builtin"
+	case tv.IsNil():
+		return "nil"
+	case tv.Value != nil:
+		return "constant"
+	case tv.Addressable():
+		return "variable"
+	case tv.IsValue():
+		return "value"
+	default:
+		return "novalue"
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/src-d/babelfish-go-driver/msg"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTypeCheck(t *testing.T) {
+	source := `package main
+
+import "fmt"
+
+const answer = 40 + 2
+
+type greeter struct{ name string }
+
+func (g *greeter) greet() string { return fmt.Sprint("hello ", g.name, answer) }
+`
+	res := getResponse(&msg.Request{Action: msg.TypeCheck, Content: source})
+	require.Equal(t, msg.Ok, res.Status, "%v", res.Errors)
+	require.NotNil(t, res.AST)
+	require.Equal(t, "main", res.TypeInfo.Package)
+
+	// 40 + 2
+	node := findTypedNode(res.TypeInfo.Types, "BinaryExpr")
+	require.NotNil(t, node)
+	require.Equal(t, &msg.TypedNode{
+		Node:  "BinaryExpr",
+		Start: msg.Position{Offset: 43, Line: 5, Column: 16},
+		End:   msg.Position{Offset: 49, Line: 5, Column: 22},
+		Mode:  "constant",
+		Type:  "untyped int",
+		Value: "42",
+	}, node)
+
+	// fmt.Sprint
+	sprint := findObject(res.TypeInfo.Uses, "Sprint")
+	require.NotNil(t, sprint)
+	require.Equal(t, "func", sprint.Kind)
+	require.Equal(t, "fmt", sprint.Package)
+	require.Nil(t, sprint.Decl)
+
+	// answer
+	use := findObject(res.TypeInfo.Uses, "answer")
+	def := findObject(res.TypeInfo.Defs, "answer")
+	require.NotNil(t, use)
+	require.NotNil(t, def)
+	require.Equal(t, "const", use.Kind)
+	require.Equal(t, def.Start, *use.Decl)
+
+	// g.name
+	require.Len(t, res.TypeInfo.Selections, 1)
+	sel := res.TypeInfo.Selections[0]
+	require.Equal(t, "field", sel.Kind)
+	require.Equal(t, "*greeter", sel.Recv)
+	require.True(t, sel.Indirect)
+	require.Equal(t, "name", sel.Obj.Name)
+}
+
+func TestGetTypeCheckUnresolvedImport(t *testing.T) {
+	source := `package main
+
+import "github.com/src-d/unknown"
+
+var x = unknown.Value
+`
+	res := getResponse(&msg.Request{Action: msg.TypeCheck, Content: source})
+	require.Equal(t, msg.Error, res.Status)
+	require.NotEmpty(t, res.Errors)
+	require.Contains(t, res.Errors[0], "source.go:3:8")
+	require.NotNil(t, res.AST)
+	require.NotNil(t, res.TypeInfo)
+}
+
+func TestGetTypeCheckDeterministic(t *testing.T) {
+	req := &msg.Request{
+		Action:  msg.TypeCheck,
+		Content: "package foo\n\nfunc F() {\n\tx, y := 1, \"a\"\n\t_ = z\n\t_ = w + x\n\t_ = (y)\n}\n",
+	}
+
+	want := getResponse(req)
+	require.Equal(t, msg.Error, want.Status)
+	require.Equal(t, []string{
+		"source.go:5:6: undefined: z",
+		"source.go:6:6: undefined: w",
+	}, want.Errors)
+
+	for i := 0; i < 20; i++ {
+		require.Equal(t, want.TypeInfo, getResponse(req).TypeInfo)
+	}
+}
+
+// findTypedNode returns the first msg.TypedNode of the given node type.
+func findTypedNode(list []*msg.TypedNode, node string) *msg.TypedNode {
+	for _, n := range list {
+		if n.Node == node {
+			return n
+		}
+	}
+
+	return nil
+}
+
+// findObject returns the first msg.Object with the given name.
+func findObject(list []*msg.Object, name string) *msg.Object {
+	for _, o := range list {
+		if o.Name == name {
+			return o
+		}
+	}
+
+	return nil
+}